@@ -0,0 +1,12 @@
+// Package favorite picks favorite numbers.
+package favorite
+
+import "math/rand"
+
+// Number returns a favorite number between 0 and max.
+func Number() int {
+	return rand.Intn(max)
+}
+
+// max is not exported, so it is only accessible from within package favorite.
+const max = 10
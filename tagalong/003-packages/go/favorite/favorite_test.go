@@ -0,0 +1,11 @@
+package favorite
+
+import "testing"
+
+func TestNumber(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if n := Number(); n < 0 || n >= max {
+			t.Fatalf("Number() = %d, want a number in [0, %d)", n, max)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"example.com/packages/favorite"
+)
+
+func main() {
+	fmt.Println("My favorite number is", favorite.Number(), "and", math.Pi)
+}
@@ -1,11 +0,0 @@
-package main
-
-import (
-	"fmt"
-	"math"
-	"math/rand"
-)
-
-func main() {
-	fmt.Println("My favorite number is", rand.Intn(10), "and", math.Pi)
-}
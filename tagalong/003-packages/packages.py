@@ -1,4 +0,0 @@
-import math
-import random
-
-print("my favorite number is",random.randint(0, 10),"and", math.pi)
\ No newline at end of file
@@ -0,0 +1,8 @@
+import random
+
+# _MAX starts with an underscore so it is not imported by "from favorite import *".
+_MAX = 10
+
+
+def number():
+    return random.randint(0, _MAX - 1)
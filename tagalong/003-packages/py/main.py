@@ -0,0 +1,5 @@
+import math
+
+import favorite
+
+print("my favorite number is", favorite.number(), "and", math.pi)
@@ -0,0 +1,15 @@
+//go:build exercise
+
+package main
+
+import "fmt"
+
+// add returns the sum of x and y.
+func add(x int, y int) int {
+	// TODO: return the sum of x and y.
+	return 0
+}
+
+func main() {
+	fmt.Println(add(42, 13))
+}
@@ -0,0 +1,26 @@
+//go:build exercise
+
+package main
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	for _, test := range []struct{ x, y, want int }{
+		{42, 13, 55},
+		{0, 0, 0},
+		{1, 1, 2},
+	} {
+		if got := add(test.x, test.y); got != test.want {
+			t.Errorf("add(%d, %d) = %d, want %d", test.x, test.y, got, test.want)
+		}
+	}
+}
+
+func TestAddNegative(t *testing.T) {
+	if got := add(-3, 5); got != 2 {
+		t.Errorf("add(-3, 5) = %d, want 2", got)
+	}
+	if got := add(-3, -5); got != -8 {
+		t.Errorf("add(-3, -5) = %d, want -8", got)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build exercise
+
+package main
+
+import "fmt"
+
+// collatz returns the two values the Collatz sequence may step to from a:
+// half of it and three times it plus one.
+func collatz(a int) (down int, up int) {
+	// TODO: assign down and up.
+	return down, up
+}
+
+func main() {
+	const v = 60
+	down, up := collatz(v)
+	fmt.Printf("empezando en %d hay que saber subir %d, y bajar %d", v, up, down)
+}
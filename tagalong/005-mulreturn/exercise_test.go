@@ -0,0 +1,21 @@
+//go:build exercise
+
+package main
+
+import "testing"
+
+func TestCollatzDown(t *testing.T) {
+	for _, a := range []int{60, 8, 2} {
+		if down, _ := collatz(a); down != a/2 {
+			t.Errorf("collatz(%d) down = %d, want %d", a, down, a/2)
+		}
+	}
+}
+
+func TestCollatzUp(t *testing.T) {
+	for _, a := range []int{60, 7, 1} {
+		if _, up := collatz(a); up != 3*a+1 {
+			t.Errorf("collatz(%d) up = %d, want %d", a, up, 3*a+1)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 func main() {
 	fmt.Println("When's Saturday?")
+	// region weekday
 	today := time.Now().Weekday()
 	switch today {
 	case time.Saturday:
@@ -18,4 +19,5 @@ func main() {
 	default:
 		fmt.Println("Too far away.")
 	}
+	// endregion
 }
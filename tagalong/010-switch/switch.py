@@ -5,6 +5,7 @@ today = datetime.date.today()
 
 # This control structure is poorly supported
 # in VSCode as of October 2022, Python 3.10. Quite hard to write.
+# region weekday
 match today.weekday():
     case 5:
         print("Today.")
@@ -13,4 +14,5 @@ match today.weekday():
     case 5:
         print("In two days.")
     case _:
-        print("Too far away.")
\ No newline at end of file
+        print("Too far away.")
+# endregion
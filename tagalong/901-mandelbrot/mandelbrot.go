@@ -2,6 +2,7 @@
 package main
 
 import (
+	"flag"
 	"image"
 	"image/color"
 	"image/png"
@@ -10,12 +11,14 @@ import (
 )
 
 func main() {
+	size := flag.Int("size", 3000, "image width in pixels, the height is two thirds of it")
+	flag.Parse()
 	fp, _ := os.Create("mandelbrot.png")
 
 	// Keep dimension aspect ratio for best results.
 	mandel := Mandelbrot{
-		imageH:  2000,
-		imageW:  3000,
+		imageH:  *size * 2 / 3,
+		imageW:  *size,
 		xmin:    -2,
 		xmax:    1,
 		ymin:    -1,
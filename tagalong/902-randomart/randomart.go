@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
@@ -10,17 +11,15 @@ import (
 	"time"
 )
 
-const (
-	imageSize = 500
-	agents    = 160
-	agentSize = imageSize / 20
-)
+const agents = 160
 
 func main() {
-	seed := time.Now().Unix() % 1000
-	rand.Seed(seed)
-	fmt.Println("agentart.png from seed", seed)
-	art := NewRandomArt(imageSize, imageSize, agents)
+	seed := flag.Int64("seed", time.Now().Unix()%1000, "random seed")
+	imageSize := flag.Int("size", 500, "image width and height in pixels")
+	flag.Parse()
+	rand.Seed(*seed)
+	fmt.Println("agentart.png from seed", *seed)
+	art := NewRandomArt(*imageSize, *imageSize, agents)
 	fp, _ := os.Create("agentart.png")
 	err := png.Encode(fp, art)
 	if err != nil {
@@ -42,6 +41,7 @@ type Agent struct {
 }
 
 func NewRandomArt(width, height, agents int) Art {
+	agentSize := width / 20
 	// Start on an empty white canvas.
 	art := Art{
 		width:  width,
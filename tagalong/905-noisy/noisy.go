@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
@@ -13,28 +14,28 @@ import (
 	noise "github.com/soypat/decaffeinator/tagalong/pkg-noise"
 )
 
-const (
-	imageSize = 1000
-	perlinDim = 10
-)
+const perlinDim = 10
 
 func main() {
-	seed := time.Now().Unix() % 1000
-	rand.Seed(seed)
-	p := Noisy{offset: rand.Float64() * 20}
+	seed := flag.Int64("seed", time.Now().Unix()%1000, "random seed")
+	size := flag.Int("size", 1000, "image width and height in pixels")
+	flag.Parse()
+	rand.Seed(*seed)
+	p := Noisy{offset: rand.Float64() * 20, size: *size}
 	fp, _ := os.Create("noisy.png")
-	fmt.Println("creating noisy.png with seed", seed)
+	fmt.Println("creating noisy.png with seed", *seed)
 	png.Encode(fp, p)
 }
 
 type Noisy struct {
 	offset float64
+	size   int
 }
 
 func (p Noisy) At(i, j int) color.Color {
 	const maxNoise = 1
 	const span = 100
-	x, y := float64(i)/imageSize, float64(j)/imageSize
+	x, y := float64(i)/float64(p.size), float64(j)/float64(p.size)
 	x += p.offset
 	y += p.offset
 	n := noise.Simplex2D(x*span, y*span)
@@ -42,6 +43,6 @@ func (p Noisy) At(i, j int) color.Color {
 	return color.RGBA{R: uint8(n * 255), A: 255}
 }
 
-func (p Noisy) Bounds() image.Rectangle { return image.Rect(0, 0, imageSize, imageSize) }
+func (p Noisy) Bounds() image.Rectangle { return image.Rect(0, 0, p.size, p.size) }
 
 func (p Noisy) ColorModel() color.Model { return color.RGBAModel }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
@@ -11,24 +12,24 @@ import (
 	"time"
 )
 
-const (
-	imageSize = 1000
-)
-
 func main() {
-	s := SDFDrawing{}
-	seed := time.Now().Unix() % 1000
-	rand.Seed(seed)
+	seed := flag.Int64("seed", time.Now().Unix()%1000, "random seed")
+	size := flag.Int("size", 1000, "image width and height in pixels")
+	flag.Parse()
+	s := SDFDrawing{size: *size}
+	rand.Seed(*seed)
 	for i := 0; i < 8; i++ {
-		sdf := TriangleSDF(randvec2(imageSize), randvec2(imageSize), randvec2(imageSize))
+		f := float64(*size)
+		sdf := TriangleSDF(randvec2(f), randvec2(f), randvec2(f))
 		s.sdfs = append(s.sdfs, sdf)
 	}
-	fmt.Println("creating sdf.png with seed", seed)
+	fmt.Println("creating sdf.png with seed", *seed)
 	fp, _ := os.Create("sdf.png")
 	png.Encode(fp, s)
 }
 
 type SDFDrawing struct {
+	size int
 	sdfs []func(vec2) float64
 }
 
@@ -45,7 +46,7 @@ func (s SDFDrawing) At(i, j int) color.Color {
 	return color.Black
 }
 
-func (s SDFDrawing) Bounds() image.Rectangle { return image.Rect(0, 0, imageSize, imageSize) }
+func (s SDFDrawing) Bounds() image.Rectangle { return image.Rect(0, 0, s.size, s.size) }
 
 func (s SDFDrawing) ColorModel() color.Model { return color.RGBAModel }
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
@@ -11,12 +12,14 @@ import (
 )
 
 const (
-	imageSize  = 200
 	stepLength = 1.0
 	iterations = 10
 )
 
 func main() {
+	size := flag.Int("size", 200, "image width and height in pixels")
+	flag.Parse()
+	imageSize := *size
 	start := time.Now()
 	rect := image.Rect(0, 0, imageSize, imageSize)
 	base := image.NewGray(rect)
@@ -27,9 +30,9 @@ func main() {
 	for it := 0; it < iterations; it++ {
 		s.SetTime(float64(it) * stepLength)
 		for ix := 0; ix < imageSize; ix++ {
-			x := float64(2*ix-imageSize) / imageSize
+			x := float64(2*ix-imageSize) / float64(imageSize)
 			for iy := 0; iy < imageSize; iy++ {
-				y := float64(2*iy-imageSize) / imageSize
+				y := float64(2*iy-imageSize) / float64(imageSize)
 				actual := s.at(x, y)
 				previousGray := base.GrayAt(ix, iy)
 				previous := float64(previousGray.Y) / 255
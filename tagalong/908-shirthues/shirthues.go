@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	_ "embed"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
@@ -19,13 +20,14 @@ var redshirt []byte
 const newFile = "newshirt.png"
 
 func main() {
-	seed := time.Now().Unix() % 1000
-	rng := rand.New(rand.NewSource(seed))
+	seed := flag.Int64("seed", time.Now().Unix()%1000, "random seed")
+	flag.Parse()
+	rng := rand.New(rand.NewSource(*seed))
 	img, _ := png.Decode(bytes.NewReader(redshirt))
 
 	newshirt, _ := os.Create(newFile)
 	defer newshirt.Close()
-	fmt.Printf("creating shirt with new hue with seed %d at %q\n", seed, newFile)
+	fmt.Printf("creating shirt with new hue with seed %d at %q\n", *seed, newFile)
 	// First layer is the color switched shirt.
 	h := newHue(rng.Float64())
 	layer1 := overlay{
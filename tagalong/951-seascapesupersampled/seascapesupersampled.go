@@ -5,6 +5,7 @@
 package main
 
 import (
+	"flag"
 	"image"
 	"image/color"
 	"image/png"
@@ -17,11 +18,8 @@ import (
 )
 
 const (
-	imageWidth       = 1920
-	imageHeight      = 1080
 	Numsteps         = 8
 	eps              = 1e-3
-	epsNorm          = 0.1 / imageWidth
 	iterGeom         = 3
 	iterFragDetailed = 5
 
@@ -35,6 +33,13 @@ const (
 	multisampleSpread = 0.75
 )
 
+// Image dimensions, set from the -size flag in main.
+var (
+	imageWidth  = 1920
+	imageHeight = 1080
+	epsNorm     = 0.1 / float64(imageWidth)
+)
+
 var (
 	seaBase          = vec3{0.0, 0.09, 0.18}
 	seaWaterColor    = scale3(0.6, vec3{0.8, 0.9, 0.6})
@@ -44,9 +49,14 @@ var (
 )
 
 func main() {
+	seedFlag := flag.Int64("seed", time.Now().Unix()%1000, "random seed")
+	size := flag.Int("size", 1920, "image width in pixels, the height keeps a 16:9 aspect ratio")
+	flag.Parse()
+	imageWidth, imageHeight = *size, *size*9/16
+	epsNorm = 0.1 / float64(imageWidth)
 	goroutines := imageHeight
 	img := image.NewRGBA(image.Rect(0, 0, imageWidth, imageHeight))
-	seed := time.Now().Unix() % 1000
+	seed := *seedFlag
 	rand.Seed(seed)
 	time := 1 + rand.Float64()*seaSpeed
 	var wg sync.WaitGroup
@@ -157,10 +167,10 @@ func mainWorkerQueue() {
 }
 
 func getPixel(coord vec2, t float64) vec3 {
-	coord.x *= 2.0 / imageWidth
-	coord.y *= 2.0 / imageHeight
+	coord.x *= 2.0 / float64(imageWidth)
+	coord.y *= 2.0 / float64(imageHeight)
 	uv := addScalar2(-1, coord)
-	uv.x *= imageWidth / imageHeight
+	uv.x *= float64(imageWidth / imageHeight)
 	// ray
 	// ang := vec3{math.Sin(3*t) * 0.1, math.Sin(t)*0.2 + 0.3, t}
 	ori := vec3{0, 3.5, t * 5}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Benchmark holds the measurements of running a vignette program several times.
+type Benchmark struct {
+	Runs int
+	// Wall is the median wall time of the runs.
+	Wall time.Duration
+	// PeakRSS is the largest peak resident set size of the runs in bytes or 0 if unknown.
+	PeakRSS int64
+	// Err is non-nil if the program could not be built or a run failed.
+	Err error
+}
+
+// benchLanguages are the languages compared by benchmarks, in table column order.
+var benchLanguages = []Language{LangGo, LangPython}
+
+// BenchVignettes benchmarks the programs in every benchmarked language of each
+// vignette with a README and a Go program. The returned slice holds the
+// benchmarks of each vignette keyed by language extension. Programs are run
+// one at a time so that their measurements do not affect each other.
+func (e *Executor) BenchVignettes(vignettes []Vignette, runs int) []map[string]Benchmark {
+	benchmarks := make([]map[string]Benchmark, len(vignettes))
+	for i := range vignettes {
+		if !runsGo(vignettes[i]) {
+			continue
+		}
+		benchmarks[i] = make(map[string]Benchmark)
+		for _, lang := range benchLanguages {
+			if vignettes[i].HasProgram(lang.Ext) {
+				benchmarks[i][lang.Ext] = e.Bench(&vignettes[i], lang, runs)
+			}
+		}
+	}
+	return benchmarks
+}
+
+// Bench runs the vignette's lang program the given number of times under the
+// executor's limits. Compiled languages are built once beforehand so that
+// only the program run is measured.
+func (e *Executor) Bench(v *Vignette, lang Language, runs int) (b Benchmark) {
+	root, err := os.MkdirTemp(e.Dir, v.Name+"-bench-")
+	if err != nil {
+		b.Err = err
+		return b
+	}
+	defer os.RemoveAll(root)
+	files := v.ProgramFiles(lang.Ext)
+	err = writeModule(root, files)
+	if err != nil {
+		b.Err = err
+		return b
+	}
+	target := files[0].Path
+	if len(v.Modules[lang.Ext]) > 0 {
+		target = "."
+	}
+	var run []string
+	switch {
+	case len(lang.Build) > 0:
+		builds := e.Builds
+		if builds == nil {
+			builds = &BuildCache{Dir: filepath.Join(root, "build")}
+		}
+		executable, output, err := builds.Build(e.Dir, v, lang, e.Limits)
+		if err != nil {
+			b.Err = fmt.Errorf("%w\n%s", err, output)
+			return b
+		}
+		run = []string{executable}
+	case target == "." && len(lang.RunModule) > 0:
+		run = lang.RunModule
+	case target != "." && len(lang.Run) > 0:
+		run = append(lang.Run[:len(lang.Run):len(lang.Run)], target)
+	default:
+		b.Err = errors.New(lang.Name + " programs can not be run")
+		return b
+	}
+	args := append(run[1:len(run):len(run)], v.Meta.Args...)
+	walls := make([]time.Duration, 0, runs)
+	for i := 0; i < runs; i++ {
+		cmd, err := e.Limits.command(run[0], args)
+		if err != nil {
+			b.Err = err
+			return b
+		}
+		cmd.Dir = root
+		cmd.Env = append(append(os.Environ(), lang.Env...), v.Meta.Env...)
+		cmd.Stdin = strings.NewReader(v.Meta.Stdin)
+		var output lockedBuffer
+		cmd.Stdout, cmd.Stderr = &output, &output
+		start := time.Now()
+		err = e.Limits.run(cmd, &output)
+		walls = append(walls, time.Since(start))
+		if err != nil {
+			b.Err = err
+			return b
+		}
+		if rss := peakRSS(cmd.ProcessState); rss > b.PeakRSS {
+			b.PeakRSS = rss
+		}
+		b.Runs++
+	}
+	sort.Slice(walls, func(i, j int) bool { return walls[i] < walls[j] })
+	b.Wall = walls[len(walls)/2]
+	return b
+}
+
+// WriteBenchmarks writes a markdown table comparing the benchmarks of each
+// vignette's programs to w, headed by the machine and toolchain versions.
+func WriteBenchmarks(w io.Writer, vignettes []Vignette, benchmarks []map[string]Benchmark, runs int) {
+	fmt.Fprintf(w, "## Performance\nMedian wall time and largest peak resident set size (RSS) of %d runs on %s.\n", runs, machineInfo())
+	if runtime.GOOS == "linux" {
+		fmt.Fprintf(w, "Peak RSS includes the memory of the generator which starts the programs, so values up to %s are upper bounds.\n", formatBytes(selfPeakRSS()))
+	}
+	for _, lang := range benchLanguages {
+		if version := strings.TrimSpace(toolchainVersion(lang)); version != "" {
+			fmt.Fprintf(w, "* %s: `%s`\n", lang.Name, version)
+		}
+	}
+	fmt.Fprint(w, "\n| Vignette |")
+	for _, lang := range benchLanguages {
+		fmt.Fprintf(w, " %s time | %s peak RSS |", lang.Name, lang.Name)
+	}
+	fmt.Fprint(w, " Speedup |\n|---|")
+	fmt.Fprint(w, strings.Repeat("---:|", 2*len(benchLanguages)+1)+"\n")
+	for i, vig := range vignettes {
+		if benchmarks[i] == nil {
+			continue
+		}
+		fmt.Fprintf(w, "| %s |", vig.Code())
+		for _, lang := range benchLanguages {
+			b, ok := benchmarks[i][lang.Ext]
+			switch {
+			case !ok:
+				fmt.Fprint(w, " - | - |")
+			case b.Err != nil:
+				fmt.Fprint(w, " failed | - |")
+			default:
+				fmt.Fprintf(w, " %s | %s |", b.Wall.Round(10*time.Microsecond), formatBytes(b.PeakRSS))
+			}
+		}
+		goBench, pyBench := benchmarks[i][LangGo.Ext], benchmarks[i][LangPython.Ext]
+		if goBench.Runs > 0 && pyBench.Runs > 0 && goBench.Err == nil && pyBench.Err == nil && goBench.Wall > 0 {
+			fmt.Fprintf(w, " %.1fx |\n", float64(pyBench.Wall)/float64(goBench.Wall))
+		} else {
+			fmt.Fprint(w, " - |\n")
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// machineInfo describes the operating system, architecture and CPU of the machine.
+func machineInfo() string {
+	info := fmt.Sprintf("%s/%s with %d CPUs", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	cpuinfo, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return info
+	}
+	for _, line := range strings.Split(string(cpuinfo), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(key) == "model name" {
+			return info + " (" + strings.TrimSpace(value) + ")"
+		}
+	}
+	return info
+}
+
+// formatBytes formats n bytes in MiB or "-" if n is 0.
+func formatBytes(n int64) string {
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+}
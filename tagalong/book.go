@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// bookTOML is the mdBook configuration of books written by WriteBook.
+const bookTOML = `[book]
+title = "Tagalong"
+src = "src"
+`
+
+// vignetteLink matches markdown links to vignette anchors, i.e: the
+// "[Packages](#003-packages)" links of the README link template function.
+var vignetteLink = regexp.MustCompile(`\[([^\]\n]*)\]\(#(\d{3}-[^)\s/#]+)\)`)
+
+// WriteBook writes the tagalong to dir as an mdBook: a book.toml and a src
+// directory with one markdown page per vignette and a SUMMARY.md listing them.
+// Links between vignettes are rewritten to point to their pages. results holds
+// the Go program result of each vignette. If benchmarks is not nil they are
+// written to a page of their own.
+func WriteBook(dir string, vignettes []Vignette, results []Result, benchmarks []map[string]Benchmark, runs int) error {
+	src := filepath.Join(dir, "src")
+	err := os.MkdirAll(src, 0o755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(dir, "book.toml"), []byte(bookTOML), 0o644)
+	if err != nil {
+		return err
+	}
+	doc := Document{Doc: DocTagalong, README: true}
+	pages := make(map[string]string) // Page filename by vignette code.
+	for _, vig := range vignettes {
+		if vig.MD != "" {
+			pages[vig.Code()] = vig.Code() + ".md"
+		}
+	}
+	var summary bytes.Buffer
+	summary.WriteString("# Summary\n\n")
+	for i, vig := range vignettes {
+		page, ok := pages[vig.Code()]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&summary, "- [%s](%s)\n", vignetteTitle(vig), page)
+		var buf bytes.Buffer
+		doc.Render(&buf, vignettes[i:i+1], results[i:i+1])
+		err = os.WriteFile(filepath.Join(src, page), rewriteVignetteLinks(buf.Bytes(), pages), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+	if benchmarks != nil {
+		const page = "performance.md"
+		fmt.Fprintf(&summary, "- [Performance](%s)\n", page)
+		var buf bytes.Buffer
+		WriteBenchmarks(&buf, vignettes, benchmarks, runs)
+		err = os.WriteFile(filepath.Join(src, page), buf.Bytes(), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filepath.Join(src, "SUMMARY.md"), summary.Bytes(), 0o644)
+}
+
+// rewriteVignetteLinks replaces the targets of links to vignette anchors
+// in md with their page in pages, keyed by vignette code. Links to vignettes
+// without a page, i.e: generative art programs, are replaced by their text.
+// Fenced code blocks are left as they are.
+func rewriteVignetteLinks(md []byte, pages map[string]string) []byte {
+	lines := bytes.SplitAfter(md, []byte("\n"))
+	fenced := false
+	for i, line := range lines {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("```")) {
+			fenced = !fenced
+			continue
+		} else if fenced {
+			continue
+		}
+		lines[i] = vignetteLink.ReplaceAllFunc(line, func(link []byte) []byte {
+			m := vignetteLink.FindSubmatch(link)
+			page, ok := pages[string(m[2])]
+			if !ok {
+				return m[1]
+			}
+			return []byte("[" + string(m[1]) + "](" + page + ")")
+		})
+	}
+	return bytes.Join(lines, nil)
+}
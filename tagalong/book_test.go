@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteBook(t *testing.T) {
+	vignettes := []Vignette{
+		{Header: Header{Num: 1, Name: "intro"}, MD: "# Introduction\nStart here.\n"},
+		{Header: Header{Num: 2, Name: "hello"}, MD: "# Hello\nSee [Introduction](#001-intro) and [Art](#901-art).\n```md\n[Introduction](#001-intro)\n```\n", Programs: map[string]string{
+			"go": "package main\n", "py": "print('hi')\n",
+		}},
+		{Header: Header{Num: 901, Name: "art"}, Programs: map[string]string{"go": "package main\n"}},
+	}
+	results := []Result{{}, {Output: "hi\n", Stdout: "hi\n"}, {}}
+	dir := t.TempDir()
+	err := WriteBook(dir, vignettes, results, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for filename, want := range map[string][]string{
+		"book.toml":        {`src = "src"`},
+		"src/SUMMARY.md":   {"- [Introduction](001-intro.md)\n- [Hello](002-hello.md)\n"},
+		"src/001-intro.md": {"Start here."},
+		"src/002-hello.md": {"See [Introduction](001-intro.md) and Art.\n", "```md\n[Introduction](#001-intro)\n```", "```plaintext\nhi\n```"},
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(filename)))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for _, s := range want {
+			if !strings.Contains(string(b), s) {
+				t.Errorf("%s does not contain %q:\n%s", filename, s, b)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src", "901-art.md")); err == nil {
+		t.Error("vignette without README has a page")
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBuild is wrapped by the error of programs which failed to compile.
+var ErrBuild = errors.New("build failed")
+
+const (
+	// maxFailedBuilds bounds the number of build failures a BuildCache remembers.
+	maxFailedBuilds = 256
+	// maxBuildAge is how long executables are kept in the build cache unused.
+	maxBuildAge = 30 * 24 * time.Hour
+	// maxBuildTmpAge is the age after which temporary executables are
+	// considered left behind by interrupted builds.
+	maxBuildTmpAge = time.Hour
+)
+
+// BuildCache compiles programs of languages with a Build command into
+// executables stored in a directory keyed by a hash of the program source,
+// the toolchain version and the environment, so that each program is built once.
+type BuildCache struct {
+	Dir string
+
+	mu sync.Mutex
+	// failed holds the compiler output of programs which failed to build by key,
+	// so that failures are not built again.
+	failed map[string]string
+}
+
+// DefaultBuildDir returns the default build cache directory in the cache directory.
+func DefaultBuildDir() string {
+	return filepath.Join(DefaultCacheDir(), "build")
+}
+
+// Build returns the filename of the executable of the vignette's lang program,
+// building it in a new directory in dir under limits if it is not in the cache.
+// If the program fails to compile the returned error wraps ErrBuild and output
+// holds the compiler output.
+func (b *BuildCache) Build(dir string, v *Vignette, lang Language, limits Limits) (executable, output string, err error) {
+	if len(lang.Build) == 0 {
+		return "", "", errors.New(lang.Name + " programs are not compiled")
+	}
+	key := b.key(v, lang)
+	executable = filepath.Join(b.Dir, key+"-"+v.Name)
+	if runtime.GOOS == "windows" {
+		executable += ".exe"
+	}
+	if _, err := os.Stat(executable); err == nil {
+		// Mark the executable as used so that Prune keeps it.
+		now := time.Now()
+		os.Chtimes(executable, now, now)
+		return executable, "", nil
+	}
+	b.mu.Lock()
+	output, failed := b.failed[key]
+	b.mu.Unlock()
+	if failed {
+		return "", output, ErrBuild
+	}
+
+	root, err := os.MkdirTemp(dir, v.Name+"-build-")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(root)
+	err = writeModule(root, v.ProgramFiles(lang.Ext))
+	if err != nil {
+		return "", "", err
+	}
+	err = os.MkdirAll(b.Dir, 0o755)
+	if err != nil {
+		return "", "", err
+	}
+	// Build to a temporary file first so concurrent builds of the same
+	// program never run a partially written executable.
+	tmp := executable + ".tmp-" + filepath.Base(root)
+	target := "."
+	if len(v.Modules[lang.Ext]) == 0 {
+		target = v.Name + "." + lang.Ext
+	}
+	args := append(lang.Build[1:len(lang.Build):len(lang.Build)], tmp, target)
+	cmd, err := limits.command(lang.Build[0], args)
+	if err != nil {
+		return "", "", err
+	}
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), lang.Env...)
+	var out lockedBuffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = limits.run(cmd, &out)
+	if exceededLimit(err) {
+		return "", out.String(), fmt.Errorf("%w: %v", ErrBuild, err)
+	} else if err != nil {
+		output = out.String()
+		b.mu.Lock()
+		if b.failed == nil {
+			b.failed = make(map[string]string)
+		}
+		for k := range b.failed {
+			if len(b.failed) < maxFailedBuilds {
+				break
+			}
+			delete(b.failed, k)
+		}
+		b.failed[key] = output
+		b.mu.Unlock()
+		return "", output, ErrBuild
+	}
+	return executable, "", os.Rename(tmp, executable)
+}
+
+// Clear removes every cached executable.
+func (b *BuildCache) Clear() error {
+	return os.RemoveAll(b.Dir)
+}
+
+// Prune removes executables which were not used for maxAge and the temporary
+// files left behind by interrupted builds.
+func (b *BuildCache) Prune(maxAge time.Duration) error {
+	entries, err := os.ReadDir(b.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed since it was listed.
+		}
+		age := time.Since(info.ModTime())
+		if age > maxAge || (strings.Contains(entry.Name(), ".tmp-") && age > maxBuildTmpAge) {
+			err = os.Remove(filepath.Join(b.Dir, entry.Name()))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// BuildVignettes builds the lang program of every vignette with a README
+// using a bounded number of workers so that the executables are cached.
+func (b *BuildCache) BuildVignettes(dir string, vignettes []Vignette, lang Language, limits Limits) {
+	parallel(len(vignettes), func(i int) {
+		if vignettes[i].HasProgram(lang.Ext) && vignettes[i].MD != "" {
+			b.Build(dir, &vignettes[i], lang, limits)
+		}
+	})
+}
+
+func (b *BuildCache) key(v *Vignette, lang Language) string {
+	h := sha256.New()
+	for _, s := range []string{lang.Ext, toolchainVersion(lang), strings.Join(lang.Build, " ")} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	for _, file := range v.ProgramFiles(lang.Ext) {
+		h.Write([]byte(file.Path))
+		h.Write([]byte{0})
+		h.Write([]byte(file.Content))
+		h.Write([]byte{0})
+	}
+	for _, s := range lang.Env {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	for _, key := range lang.CacheEnv {
+		h.Write([]byte(key + "=" + os.Getenv(key)))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
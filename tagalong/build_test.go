@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExecuteVignettesBuildsOnCacheMiss(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go")
+	}
+	dir := t.TempDir()
+	executor := &Executor{
+		Dir:    dir,
+		Limits: Limits{Timeout: time.Minute},
+		Cache:  &Cache{Dir: filepath.Join(dir, "cache")},
+		Builds: &BuildCache{Dir: filepath.Join(dir, "build")},
+	}
+	vignettes := []Vignette{{Header: Header{Num: 1, Name: "hello"}, MD: "# Hello\n",
+		Programs: map[string]string{"go": "package main\n\nfunc main() { println(\"hi\") }\n"}}}
+	res := executor.ExecuteVignettes(vignettes, LangGo)
+	if res[0].Err != nil || res[0].Cached {
+		t.Fatalf("first run: got %+v, want uncached success", res[0])
+	}
+	err := executor.Builds.Clear()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = executor.ExecuteVignettes(vignettes, LangGo)
+	if res[0].Err != nil || !res[0].Cached || res[0].Stderr != "hi\n" {
+		t.Fatalf("second run: got %+v, want cached result", res[0])
+	}
+	if _, err := os.Stat(executor.Builds.Dir); !os.IsNotExist(err) {
+		t.Errorf("program with a cached result was built again")
+	}
+}
+
+func TestBuildCachePrune(t *testing.T) {
+	b := &BuildCache{Dir: t.TempDir()}
+	old := time.Now().Add(-2 * maxBuildTmpAge)
+	for name, mtime := range map[string]time.Time{
+		"key-used":               time.Now(),
+		"key-unused":             old.Add(-maxBuildAge),
+		"key-hello.tmp-building": time.Now(),
+		"key-hello.tmp-left":     old,
+	} {
+		filename := filepath.Join(b.Dir, name)
+		err := os.WriteFile(filename, nil, 0o755)
+		if err == nil {
+			err = os.Chtimes(filename, mtime, mtime)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := b.Prune(maxBuildAge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := dirEntries(t, b.Dir)
+	if want := []string{"key-hello.tmp-building", "key-used"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if err := (&BuildCache{Dir: filepath.Join(b.Dir, "missing")}).Prune(maxBuildAge); err != nil {
+		t.Errorf("pruning missing directory: %v", err)
+	}
+}
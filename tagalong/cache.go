@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// cacheVersion is hashed into every cache key. Bump it whenever the layout of
+// cache entries, the values keys are hashed from or the post-processing of
+// program output changes so that entries stored by earlier versions are not used.
+const cacheVersion = "4"
+
+// Cache stores the results of successful program runs in a directory keyed by a hash
+// of the program source, the language toolchain and its commands and the environment.
+type Cache struct {
+	Dir string
+
+	hits, misses int32
+}
+
+type cacheEntry struct {
+	Output   string
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// DefaultCacheDir returns the default cache directory in the user's cache directory.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "tagalong")
+}
+
+// Get returns the cached result of running the vignette's lang program.
+func (c *Cache) Get(v *Vignette, lang Language) (Result, bool) {
+	b, err := os.ReadFile(c.path(v, lang))
+	var entry cacheEntry
+	if err == nil {
+		err = json.Unmarshal(b, &entry)
+	}
+	if err != nil {
+		atomic.AddInt32(&c.misses, 1)
+		return Result{}, false
+	}
+	atomic.AddInt32(&c.hits, 1)
+	return Result{Output: entry.Output, Stdout: entry.Stdout, Stderr: entry.Stderr, ExitCode: entry.ExitCode, Cached: true}, true
+}
+
+// Put stores the result of running the vignette's lang program. Failed runs are not stored.
+func (c *Cache) Put(v *Vignette, lang Language, res Result) error {
+	if res.Err != nil {
+		return nil
+	}
+	b, err := json.Marshal(cacheEntry{Output: res.Output, Stdout: res.Stdout, Stderr: res.Stderr, ExitCode: res.ExitCode})
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(c.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so concurrent readers never see a partial entry.
+	path := c.path(v, lang)
+	// Each writer has its own so that concurrent writers do not interleave.
+	tmp, err := os.CreateTemp(c.Dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Fails once renamed.
+	_, err = tmp.Write(b)
+	if err == nil {
+		err = tmp.Chmod(0o644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Clear removes every cache entry.
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.Dir)
+}
+
+// Stats returns the number of cache hits and misses.
+func (c *Cache) Stats() (hits, misses int) {
+	return int(atomic.LoadInt32(&c.hits)), int(atomic.LoadInt32(&c.misses))
+}
+
+func (c *Cache) path(v *Vignette, lang Language) string {
+	h := sha256.New()
+	for _, s := range []string{cacheVersion, lang.Ext, toolchainVersion(lang), v.Meta.Stdin, strconv.Itoa(v.Meta.Exit)} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	for _, file := range v.ProgramFiles(lang.Ext) {
+		h.Write([]byte(file.Path))
+		h.Write([]byte{0})
+		h.Write([]byte(file.Content))
+		h.Write([]byte{0})
+	}
+	for _, list := range [][]string{lang.Run, lang.RunModule, lang.Build, lang.Env, v.Meta.Args, v.Meta.Env} {
+		for _, s := range list {
+			h.Write([]byte(s))
+			h.Write([]byte{0})
+		}
+		h.Write([]byte{0})
+	}
+	for _, key := range lang.CacheEnv {
+		h.Write([]byte(key + "=" + os.Getenv(key)))
+		h.Write([]byte{0})
+	}
+	return filepath.Join(c.Dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+var (
+	versionsMu sync.Mutex
+	versions   = make(map[string]string) // Toolchain version by language extension.
+)
+
+// toolchainVersion returns the output of the language's version command or
+// the empty string if it has none or it fails. It is run once per language.
+func toolchainVersion(lang Language) string {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	if v, ok := versions[lang.Ext]; ok {
+		return v
+	}
+	var v string
+	if len(lang.Version) > 0 {
+		out, err := exec.Command(lang.Version[0], lang.Version[1:]...).Output()
+		if err == nil {
+			v = string(out)
+		}
+	}
+	versions[lang.Ext] = v
+	return v
+}
+
+// LogCacheStats logs the cache hits and misses of the executor's cache, if any.
+func (e *Executor) LogCacheStats() {
+	if e.Cache == nil {
+		return
+	}
+	hits, misses := e.Cache.Stats()
+	log.Printf("cache: %d hits, %d misses", hits, misses)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCacheKey(t *testing.T) {
+	c := &Cache{Dir: t.TempDir()}
+	v := &Vignette{Header: Header{Num: 2, Name: "hello"}, Programs: map[string]string{"go": "package main\n"}}
+	build := LangGo
+	build.Build = []string{"go", "build", "-o"}
+	run := LangGo
+	run.Run = []string{"go", "run", "-race"}
+	env := LangGo
+	env.Env = nil
+	paths := map[string]string{"go": c.path(v, LangGo)}
+	for name, lang := range map[string]Language{"build": build, "run": run, "env": env} {
+		path := c.path(v, lang)
+		for other, p := range paths {
+			if p == path {
+				t.Errorf("%s and %s languages have the same cache key", name, other)
+			}
+		}
+		paths[name] = path
+	}
+
+	err := c.Put(v, LangGo, Result{Output: "hi\n", Stdout: "hi\n", Duration: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, ok := c.Get(v, LangGo)
+	if !ok || res.Stdout != "hi\n" || !res.Cached || res.Duration != 0 {
+		t.Errorf("Get after Put: got %+v, %v, want cached result without duration", res, ok)
+	}
+}
+
+// TestCachePutConcurrent checks that concurrent writers of an entry leave a
+// complete entry and no temporary files behind.
+func TestCachePutConcurrent(t *testing.T) {
+	c := &Cache{Dir: t.TempDir()}
+	v := &Vignette{Header: Header{Num: 2, Name: "hello"}, Programs: map[string]string{"go": "package main\n"}}
+	errs := make([]error, 16)
+	parallel(len(errs), func(i int) {
+		errs[i] = c.Put(v, LangGo, Result{Output: "hi\n", Stdout: "hi\n"})
+	})
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, ok := c.Get(v, LangGo)
+	if !ok || res.Stdout != "hi\n" {
+		t.Errorf("Get after concurrent Put: got %+v, %v", res, ok)
+	}
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("want a single cache entry, got %d files", len(entries))
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Check renders the document and compares it to the document file in dir.
+// It returns a unified diff for each section of the file which differs from
+// the rendered document or the empty string if the file is up to date.
+// Sections start at the anchors of the table of contents and of each vignette.
+// Pointer addresses and the output of nondeterministic programs vary between
+// program runs and are not considered a difference.
+func (d Document) Check(dir string, vignettes []Vignette, results []Result) (string, error) {
+	onDisk, err := os.ReadFile(filepath.Join(dir, d.Filename))
+	if err != nil {
+		return "", err
+	}
+	var rendered bytes.Buffer
+	d.Render(&rendered, vignettes, results)
+	if bytes.Equal(maskVarying(onDisk), maskVarying(rendered.Bytes())) {
+		return "", nil
+	}
+	oldSections, names := splitSections(onDisk)
+	newSections, newNames := splitSections(rendered.Bytes())
+	for _, name := range newNames {
+		if _, ok := oldSections[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	var diffs strings.Builder
+	for _, name := range names {
+		before, after := oldSections[name], newSections[name]
+		if bytes.Equal(maskVarying(before), maskVarying(after)) {
+			continue
+		}
+		section := d.Filename
+		if name != "" {
+			section += "#" + name
+		}
+		diffs.WriteString(UnifiedDiff(section, section+" (rendered)", string(before), string(after)))
+	}
+	return diffs.String(), nil
+}
+
+// sectionAnchor matches the anchor starting a section of a rendered document.
+var sectionAnchor = regexp.MustCompile(`(?m)^<a id="([^"]+)"></a>$`)
+
+// splitSections splits a rendered document into sections keyed by the name
+// of their anchor and returns the names in document order. Text before the
+// first anchor is the section named "".
+func splitSections(doc []byte) (sections map[string][]byte, names []string) {
+	sections = make(map[string][]byte)
+	add := func(name string, text []byte) {
+		if _, ok := sections[name]; !ok {
+			names = append(names, name)
+		}
+		sections[name] = append(sections[name], text...)
+	}
+	start, name := 0, ""
+	for _, m := range sectionAnchor.FindAllSubmatchIndex(doc, -1) {
+		if m[0] > start || name != "" {
+			add(name, doc[start:m[0]])
+		}
+		start, name = m[0], string(doc[m[2]:m[3]])
+	}
+	add(name, doc[start:])
+	return sections, names
+}
+
+var (
+	// outputBlockText matches a rendered block of the output or standard error
+	// of a program, capturing the text around its content.
+	outputBlockText = regexp.MustCompile("(\\*\\*(?:Output|Standard error)\\*\\*:\n```plaintext\n)(?s:.*?)(\n```\n\n)")
+	// nondeterministicOutput matches the note of nondeterministic programs
+	// and the output blocks following it.
+	nondeterministicOutput = regexp.MustCompile(regexp.QuoteMeta(nondeterministicNote) + "\n\n(?:" + outputBlockText.String() + ")+")
+)
+
+// maskVarying masks the parts of a rendered document which vary between program
+// runs: the output and standard error of nondeterministic programs and addresses
+// in the output of other programs. Program source is never masked.
+func maskVarying(b []byte) []byte {
+	b = nondeterministicOutput.ReplaceAllFunc(b, func(blocks []byte) []byte {
+		return outputBlockText.ReplaceAll(blocks, []byte("${1}?${2}"))
+	})
+	return outputBlockText.ReplaceAllFunc(b, maskAddresses)
+}
+
+var hexAddress = regexp.MustCompile(`0x[0-9a-f]{8,16}`)
+
+func maskAddresses(b []byte) []byte {
+	return hexAddress.ReplaceAll(b, []byte("0x?"))
+}
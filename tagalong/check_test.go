@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckSections(t *testing.T) {
+	vignettes := []Vignette{
+		{Header: Header{Num: 1, Name: "intro"}, MD: "# Introduction\n"},
+		{Header: Header{Num: 2, Name: "hello"}, MD: "# Hello\n", Programs: map[string]string{"go": "package main\n", "py": "print('hi')\n"}},
+		{Header: Header{Num: 3, Name: "bye"}, MD: "# Bye\n", Programs: map[string]string{"go": "package main\n", "py": "print('bye')\n"}},
+	}
+	results := []Result{{}, {Output: "hi\n", Stdout: "hi\n"}, {Output: "bye\n", Stdout: "bye\n"}}
+	doc := Document{Doc: DocTagalong, Filename: "doc.md", README: true, TOC: true}
+	var buf bytes.Buffer
+	doc.Render(&buf, vignettes, results)
+	dir := t.TempDir()
+	write := func(md string) {
+		err := os.WriteFile(filepath.Join(dir, doc.Filename), []byte(md), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(buf.String())
+	diff, err := doc.Check(dir, vignettes, results)
+	if err != nil || diff != "" {
+		t.Fatalf("up to date document: got diff %q, error %v", diff, err)
+	}
+
+	write(strings.Replace(buf.String(), "```plaintext\nhi\n```", "```plaintext\nhello\n```", 1))
+	diff, err = doc.Check(dir, vignettes, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(diff, "--- doc.md#002-hello\n") || strings.Count(diff, "--- ") != 1 || !strings.Contains(diff, "-hello\n+hi\n") {
+		t.Errorf("stale section: want a diff of section 002-hello only, got\n%s", diff)
+	}
+
+	write(strings.Replace(buf.String(), `<a id="003-bye"></a>`, "", 1))
+	diff, err = doc.Check(dir, vignettes, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "--- doc.md#002-hello\n") || !strings.Contains(diff, "--- doc.md#003-bye\n") {
+		t.Errorf("missing section: want diffs of sections 002-hello and 003-bye, got\n%s", diff)
+	}
+}
+
+func TestMaskVarying(t *testing.T) {
+	vig := Vignette{Header: Header{Num: 1, Name: "race"}, MD: "# Race\n", Meta: Meta{Nondeterministic: true},
+		Programs: map[string]string{"go": "package main\n", "py": "print()\n"}}
+	doc := Document{Doc: DocTagalong, README: true}
+	render := func(res Result) []byte {
+		var buf bytes.Buffer
+		doc.Render(&buf, []Vignette{vig}, []Result{res})
+		return maskVarying(buf.Bytes())
+	}
+	for _, test := range []struct {
+		name string
+		a, b Result
+	}{
+		{name: "output", a: Result{Stdout: "1 2\n"}, b: Result{Stdout: "2 1\n"}},
+		{name: "stderr", a: Result{Stdout: "x\n", Stderr: "1 2\n"}, b: Result{Stdout: "y\n", Stderr: "2 1\n"}},
+		{name: "stderr only", a: Result{Stderr: "1 2\n"}, b: Result{Stderr: "2 1\n"}},
+	} {
+		a, b := render(test.a), render(test.b)
+		if !bytes.Equal(a, b) {
+			t.Errorf("%s: masked documents differ:\n%s", test.name, UnifiedDiff("a", "b", string(a), string(b)))
+		}
+	}
+	// Output of deterministic programs is not masked, but addresses in it are.
+	vig.Meta.Nondeterministic = false
+	if a, b := render(Result{Stdout: "1 2\n"}), render(Result{Stdout: "2 1\n"}); bytes.Equal(a, b) {
+		t.Errorf("deterministic output masked:\n%s", a)
+	}
+	if a, b := render(Result{Stdout: "0xc000012345\n"}), render(Result{Stdout: "0xc000054321\n"}); !bytes.Equal(a, b) {
+		t.Errorf("addresses in output not masked:\n%s", UnifiedDiff("a", "b", string(a), string(b)))
+	}
+	// Constants in program source are not addresses.
+	vig.Programs["go"] = "package main\n\nconst mask = 0x12345678\n"
+	a := render(Result{})
+	vig.Programs["go"] = "package main\n\nconst mask = 0x87654321\n"
+	if b := render(Result{}); bytes.Equal(a, b) {
+		t.Errorf("program source masked:\n%s", b)
+	}
+}
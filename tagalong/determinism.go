@@ -0,0 +1,38 @@
+package main
+
+import "fmt"
+
+// VerifyDeterminism runs the Go program of every vignette with a README again
+// the given number of times, bypassing the cache, and returns the vignettes whose
+// output differs between runs, diffing their first differing run against the
+// vignette's result in results.
+func VerifyDeterminism(e *Executor, vignettes []Vignette, results []Result, runs int) []Divergence {
+	uncached := *e
+	uncached.Cache = nil
+	found := make([]*Divergence, len(vignettes))
+	parallel(len(vignettes), func(i int) {
+		vignette := vignettes[i]
+		if !runsGo(vignette) || results[i].Err != nil {
+			return
+		}
+		for run := 1; run <= runs; run++ {
+			res := uncached.Execute(&vignette, LangGo)
+			if res.Err != nil {
+				found[i] = &Divergence{Header: vignette.Header, Err: res.Err}
+				return
+			}
+			if res.Stdout != results[i].Stdout || res.Stderr != results[i].Stderr {
+				diff := UnifiedDiff("first run", fmt.Sprintf("run %d", run+1), results[i].Output, res.Output)
+				found[i] = &Divergence{Header: vignette.Header, Diff: diff}
+				return
+			}
+		}
+	})
+	var divergences []Divergence
+	for _, d := range found {
+		if d != nil {
+			divergences = append(divergences, *d)
+		}
+	}
+	return divergences
+}
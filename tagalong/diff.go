@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each hunk.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ' for unchanged, '-' for removed, '+' for added.
+	text string
+}
+
+// UnifiedDiff returns a unified diff between a and b named aName and bName
+// respectively. It returns the empty string if a and b have the same lines.
+// A missing newline at the end of the text is not considered a difference.
+func UnifiedDiff(aName, bName, a, b string) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+	changed := false
+	for _, op := range ops {
+		changed = changed || op.kind != ' '
+	}
+	if !changed {
+		return ""
+	}
+	// aline[k] and bline[k] are the number of lines of a and b consumed before ops[k].
+	aline := make([]int, len(ops)+1)
+	bline := make([]int, len(ops)+1)
+	for k, op := range ops {
+		aline[k+1], bline[k+1] = aline[k], bline[k]
+		if op.kind != '+' {
+			aline[k+1]++
+		}
+		if op.kind != '-' {
+			bline[k+1]++
+		}
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", aName, bName)
+	for start := 0; start < len(ops); {
+		change := start
+		for change < len(ops) && ops[change].kind == ' ' {
+			change++
+		}
+		if change == len(ops) {
+			break
+		}
+		hunkStart := change - diffContext
+		if hunkStart < start {
+			hunkStart = start
+		}
+		// Extend the hunk until a run of unchanged lines too long to be shared
+		// as context between two consecutive hunks is found.
+		hunkEnd := change
+		for hunkEnd < len(ops) {
+			if ops[hunkEnd].kind != ' ' {
+				hunkEnd++
+				continue
+			}
+			run := hunkEnd
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-hunkEnd > 2*diffContext {
+				hunkEnd += diffContext
+				if hunkEnd > run {
+					hunkEnd = run
+				}
+				break
+			}
+			hunkEnd = run
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n",
+			hunkRange(aline[hunkStart], aline[hunkEnd]-aline[hunkStart]),
+			hunkRange(bline[hunkStart], bline[hunkEnd]-bline[hunkStart]))
+		for _, op := range ops[hunkStart:hunkEnd] {
+			buf.WriteByte(op.kind)
+			buf.WriteString(op.text)
+			buf.WriteByte('\n')
+		}
+		start = hunkEnd
+	}
+	return buf.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// diffLines returns the edit script that transforms a into b using
+// the longest common subsequence of lines.
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	// Common prefix and suffix are trimmed to keep the LCS table small
+	// for long documents with few changes.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		ops = append(ops, diffOp{kind: ' ', text: a[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(ma), len(mb)
+	// lcs[i][j] is the length of the longest common subsequence of ma[i:] and mb[j:].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case ma[i] == mb[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case ma[i] == mb[j]:
+			ops = append(ops, diffOp{kind: ' ', text: ma[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{kind: '-', text: ma[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', text: mb[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{kind: '-', text: ma[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{kind: '+', text: mb[j]})
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{kind: ' ', text: line})
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
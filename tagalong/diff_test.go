@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numbered returns lines "1" to "n" with the lines at the given numbers replaced.
+func numbered(n int, replace map[int]string) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		line, ok := replace[i]
+		if !ok {
+			line = fmt.Sprint(i)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "identical", a: "a\nb\n", b: "a\nb\n", want: ""},
+		{name: "empty", a: "", b: "", want: ""},
+		{name: "missing trailing newline", a: "a\nb\n", b: "a\nb", want: ""},
+		{name: "insertion", a: "a\nb\n", b: "a\nx\nb\n", want: "@@ -1,2 +1,3 @@\n a\n+x\n b\n"},
+		{name: "insertion into empty", a: "", b: "a\nb\n", want: "@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{name: "deletion", a: "a\nb\nc\n", b: "a\nc\n", want: "@@ -1,3 +1,2 @@\n a\n-b\n c\n"},
+		{name: "deletion of everything", a: "a\n", b: "", want: "@@ -1 +0,0 @@\n-a\n"},
+		{name: "trailing line changed", a: "a\nb\n", b: "a\nc", want: "@@ -1,2 +1,2 @@\n a\n-b\n+c\n"},
+		{
+			name: "context limited to 3 lines",
+			a:    numbered(10, nil),
+			b:    numbered(10, map[int]string{5: "x"}),
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n",
+		},
+		{
+			name: "hunks with shared context merged",
+			a:    numbered(20, nil),
+			b:    numbered(20, map[int]string{5: "x", 11: "y"}),
+			want: "@@ -2,13 +2,13 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n 9\n 10\n-11\n+y\n 12\n 13\n 14\n",
+		},
+		{
+			name: "distant hunks split",
+			a:    numbered(20, nil),
+			b:    numbered(20, map[int]string{5: "x", 15: "y"}),
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+x\n 6\n 7\n 8\n@@ -12,7 +12,7 @@\n 12\n 13\n 14\n-15\n+y\n 16\n 17\n 18\n",
+		},
+	} {
+		want := test.want
+		if want != "" {
+			want = "--- a\n+++ b\n" + want
+		}
+		if got := UnifiedDiff("a", "b", test.a, test.b); got != want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of running a vignette program.
+type Result struct {
+	// Output is the program's standard output and standard error interleaved.
+	Output string
+	Stdout string
+	Stderr string
+	// ExitCode is the exit code of the program or -1 if it did not exit normally.
+	ExitCode int
+	// Duration is the wall time of the run. It is zero for cached results.
+	Duration time.Duration
+	// Cached is set if the result was read from the cache instead of running the program.
+	Cached bool
+	// Err is non-nil if the program could not be run or did not succeed.
+	Err error
+}
+
+var (
+	// ErrExitCode is wrapped by the error of Go programs which exit with
+	// another code than declared in their metadata.
+	ErrExitCode    = errors.New("unexpected exit code")
+	ErrTimeout     = errors.New("timed out")
+	ErrCPULimit    = errors.New("CPU time limit exceeded")
+	ErrMemoryLimit = errors.New("address space limit exceeded")
+)
+
+// Executor runs vignette programs.
+type Executor struct {
+	// Dir is the directory programs are written to before being run.
+	Dir    string
+	Limits Limits
+	// Cache stores results of successful runs if not nil.
+	Cache *Cache
+	// Builds compiles programs of languages with a Build command before
+	// they are run if not nil. Otherwise they are run from source.
+	Builds *BuildCache
+}
+
+// NewExecutor returns an executor which writes programs to dir and compiles
+// the programs of languages with a Build command into the build cache in
+// buildDir before running them, the way documents are generated.
+func NewExecutor(dir, buildDir string, limits Limits) *Executor {
+	return &Executor{Dir: dir, Limits: limits, Builds: &BuildCache{Dir: buildDir}}
+}
+
+// Execute runs the vignette's lang program or returns its cached result.
+func (e *Executor) Execute(v *Vignette, lang Language) Result {
+	if e.Cache != nil {
+		if res, ok := e.Cache.Get(v, lang); ok {
+			return res
+		}
+	}
+	return e.run(v, lang)
+}
+
+// run builds and runs the vignette's lang program, caching the result.
+func (e *Executor) run(v *Vignette, lang Language) Result {
+	var executable string
+	if e.Builds != nil && len(lang.Build) > 0 {
+		var output string
+		var err error
+		executable, output, err = e.Builds.Build(e.Dir, v, lang, e.Limits)
+		if err != nil {
+			return Result{Output: output, Stderr: output, ExitCode: -1, Err: err}
+		}
+	}
+	res := v.execute(e.Dir, lang, e.Limits, executable)
+	if e.Cache != nil {
+		if err := e.Cache.Put(v, lang, res); err != nil {
+			log.Println("caching result of", v.Name, err)
+		}
+	}
+	return res
+}
+
+// Limits bounds the resources used to run a vignette program. Zero values mean no limit.
+// CPU time and address space limits apply to every process started by the run command,
+// which for `go run` includes the compiler.
+type Limits struct {
+	// Timeout is the wall time after which the program is killed. On Unix the
+	// processes it started are killed along with it.
+	Timeout time.Duration
+	// CPUTime is the CPU time limit of each process, rounded up to whole seconds.
+	CPUTime time.Duration
+	// AddressSpace is the virtual memory limit of each process in bytes.
+	// Beware the Go runtime reserves a lot of address space upfront.
+	AddressSpace int64
+}
+
+// exceededLimit reports whether err is the result of a program exceeding its limits.
+func exceededLimit(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, ErrCPULimit) || errors.Is(err, ErrMemoryLimit)
+}
+
+// command returns the command that runs name with args under the CPU time and address space limits.
+func (l Limits) command(name string, args []string) (*exec.Cmd, error) {
+	if l.CPUTime <= 0 && l.AddressSpace <= 0 {
+		return exec.Command(name, args...), nil
+	}
+	return rlimitCommand(l, name, args)
+}
+
+// run runs cmd, killing it on timeout. output is the program output used to
+// identify resource limit hits.
+func (l Limits) run(cmd *exec.Cmd, output fmt.Stringer) error {
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if l.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.Timeout)
+		defer cancel()
+	}
+	timedOut, err := waitKilling(ctx, cmd)
+	switch {
+	case err == nil:
+		return nil
+	case timedOut && !exitedNormally(cmd.ProcessState):
+		// The deadline may pass as the program exits on its own.
+		return fmt.Errorf("%w after %s", ErrTimeout, l.Timeout)
+	case l.CPUTime > 0 && l.exceededCPUTime(cmd.ProcessState, err, output.String()):
+		return fmt.Errorf("%w (%s)", ErrCPULimit, l.CPUTime)
+	case l.AddressSpace > 0 && strings.Contains(output.String(), "out of memory"):
+		return fmt.Errorf("%w (%d bytes)", ErrMemoryLimit, l.AddressSpace)
+	}
+	return err
+}
+
+// exitedNormally reports whether the process exited on its own rather than
+// being killed by a signal.
+func exitedNormally(state *os.ProcessState) bool {
+	return state != nil && state.Exited()
+}
+
+// cpuTime returns the CPU time limit rounded up to whole seconds, as it is set.
+func (l Limits) cpuTime() time.Duration {
+	return (l.CPUTime + time.Second - 1) / time.Second * time.Second
+}
+
+// exceededCPUTime reports whether the process which exited with state and err,
+// or a process it started, i.e: the binary built by `go run`, was killed for
+// exceeding the CPU time limit. A SIGKILL is only attributed to the limit if
+// the processes used most of the limit, since the out of memory killer sends
+// it too. The CPU time reported for a process may fall short of the time the
+// kernel enforces the limit against by a tenth of a second or more under load.
+func (l Limits) exceededCPUTime(state *os.ProcessState, err error, output string) bool {
+	xcpu, kill := signaled(err)
+	if xcpu {
+		return true
+	}
+	if !kill && !strings.Contains(output, "signal: killed") {
+		return false
+	}
+	// The CPU time of a process includes that of the processes it waited for.
+	return state != nil && state.UserTime()+state.SystemTime() >= l.cpuTime()*3/4
+}
+
+// sanitizePaths replaces the temporary directory dir in paths found in
+// program output, i.e: in panic stack traces, with "." so that the output
+// does not depend on where the program was run from.
+func sanitizePaths(s, dir string) string {
+	dirs := []string{dir}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil && resolved != dir {
+		dirs = append(dirs, resolved)
+	}
+	for _, d := range dirs {
+		s = strings.ReplaceAll(s, d+string(filepath.Separator), "."+string(filepath.Separator))
+	}
+	return s
+}
+
+// parallel calls fn for every i in [0, n) from GOMAXPROCS goroutines.
+func parallel(n int, fn func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// pPID is the idtype_t of waitid which selects a process by its id.
+const pPID = 1
+
+// waitKilling waits for cmd to exit and kills its process group when ctx is
+// done, reporting whether it did. The group is also killed when the program
+// exits so that no process it started outlives the run, holding its output
+// open. The group is only signalled before Wait reaps its leader, as its id
+// may be reused by another process group afterwards.
+func waitKilling(ctx context.Context, cmd *exec.Cmd) (killed bool, err error) {
+	exited := make(chan error, 1)
+	go func() {
+		exited <- waitExited(cmd.Process.Pid)
+	}()
+	select {
+	case <-ctx.Done():
+		killed = true
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-exited
+	case err := <-exited:
+		if err == nil {
+			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+	}
+	return killed, cmd.Wait()
+}
+
+// waitExited blocks until the child process pid exits without reaping it.
+func waitExited(pid int) error {
+	var info [128]byte // siginfo_t, which is left unread.
+	for {
+		_, _, errno := syscall.Syscall6(syscall.SYS_WAITID, pPID, uintptr(pid), uintptr(unsafe.Pointer(&info)), syscall.WEXITED|syscall.WNOWAIT, 0, 0)
+		switch errno {
+		case 0:
+			return nil
+		case syscall.EINTR:
+			continue
+		}
+		return errno
+	}
+}
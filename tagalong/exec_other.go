@@ -0,0 +1,41 @@
+//go:build !unix
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// waitKilling waits for cmd to exit and kills it when ctx is done, reporting
+// whether it did. Processes it started are not killed.
+func waitKilling(ctx context.Context, cmd *exec.Cmd) (killed bool, err error) {
+	done := make(chan struct{})
+	result := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			cmd.Process.Kill()
+			result <- true
+		case <-done:
+			result <- false
+		}
+	}()
+	err = cmd.Wait()
+	close(done)
+	return <-result, err
+}
+
+func rlimitCommand(l Limits, name string, args []string) (*exec.Cmd, error) {
+	return nil, errors.New("CPU time and address space limits not supported on this platform")
+}
+
+func signaled(err error) (xcpu, kill bool) { return false, false }
+
+func peakRSS(state *os.ProcessState) int64 { return 0 }
+
+func selfPeakRSS() int64 { return 0 }
@@ -0,0 +1,78 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup makes cmd the leader of a new process group so that the
+// processes it starts, i.e: the binary built by `go run`, can be killed with it.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// rlimitCommand runs name with args from a shell which sets the resource
+// limits beforehand so they are inherited by every process the command starts.
+func rlimitCommand(l Limits, name string, args []string) (*exec.Cmd, error) {
+	script := ""
+	if l.CPUTime > 0 {
+		script += "ulimit -t " + strconv.FormatInt(int64(l.cpuTime()/time.Second), 10) + " && "
+	}
+	if l.AddressSpace > 0 {
+		kib := (l.AddressSpace + 1023) / 1024
+		script += "ulimit -v " + strconv.FormatInt(kib, 10) + " && "
+	}
+	script += `exec "$@"`
+	return exec.Command("/bin/sh", append([]string{"-c", script, "sh", name}, args...)...), nil
+}
+
+// signaled reports whether err is the result of the process being killed by
+// a signal sent when exceeding its CPU time limit: SIGXCPU past the soft limit
+// or SIGKILL at the hard limit. SIGKILL is also sent for other reasons, i.e:
+// by the out of memory killer.
+func signaled(err error) (xcpu, kill bool) {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false, false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return false, false
+	}
+	return status.Signal() == syscall.SIGXCPU, status.Signal() == syscall.SIGKILL
+}
+
+// peakRSS returns the maximum resident set size in bytes of the exited process.
+// On Linux it is at least the resident set size of this process when the
+// process was started, since it is recorded when the child replaces its copy
+// of our address space, see [selfPeakRSS].
+func peakRSS(state *os.ProcessState) int64 {
+	usage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return 0
+	}
+	return maxrssBytes(int64(usage.Maxrss))
+}
+
+// selfPeakRSS returns the maximum resident set size in bytes of this process.
+func selfPeakRSS() int64 {
+	var usage syscall.Rusage
+	if syscall.Getrusage(syscall.RUSAGE_SELF, &usage) != nil {
+		return 0
+	}
+	return maxrssBytes(int64(usage.Maxrss))
+}
+
+func maxrssBytes(maxrss int64) int64 {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "ios" {
+		return maxrss // Already in bytes.
+	}
+	return maxrss * 1024
+}
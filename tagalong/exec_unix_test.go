@@ -0,0 +1,42 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimitsRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("uses up a second of CPU time")
+	}
+	for _, test := range []struct {
+		name   string
+		limits Limits
+		script string
+		want   error
+	}{
+		{name: "timeout", limits: Limits{Timeout: 100 * time.Millisecond, CPUTime: time.Second}, script: "sleep 10", want: ErrTimeout},
+		{name: "cpu", limits: Limits{Timeout: time.Minute, CPUTime: time.Second}, script: "while :; do :; done", want: ErrCPULimit},
+		// A SIGKILL from anyone else, i.e: the out of memory killer, is not a CPU time limit hit.
+		{name: "killed", limits: Limits{Timeout: time.Minute, CPUTime: time.Second}, script: "kill -9 $$"},
+		{name: "success", limits: Limits{Timeout: time.Minute, CPUTime: time.Second}, script: "true"},
+	} {
+		cmd, err := test.limits.command("/bin/sh", []string{"-c", test.script})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var output lockedBuffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		err = test.limits.run(cmd, &output)
+		switch {
+		case test.want != nil && !errors.Is(err, test.want):
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.want)
+		case test.want == nil && exceededLimit(err):
+			t.Errorf("%s: got error %v, want no limit exceeded", test.name, err)
+		}
+	}
+}
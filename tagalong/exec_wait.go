@@ -0,0 +1,30 @@
+//go:build unix && !linux
+
+package main
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// waitKilling waits for cmd to exit and kills its process group when ctx is
+// done, reporting whether it did. Unlike on Linux the group is not killed when
+// the program exits on its own, as the leader can not be waited for without
+// reaping it, which may free the group id for reuse.
+func waitKilling(ctx context.Context, cmd *exec.Cmd) (killed bool, err error) {
+	done := make(chan struct{})
+	result := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+			result <- true
+		case <-done:
+			result <- false
+		}
+	}()
+	err = cmd.Wait()
+	close(done)
+	return <-result, err
+}
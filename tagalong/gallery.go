@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GalleryFilename is the name of the gallery document written by the gallery subcommand.
+const GalleryFilename = "gallery.md"
+
+// GalleryEntry is the outcome of running a generative art program for the gallery.
+type GalleryEntry struct {
+	Header
+	// Source is the path of the program's source file.
+	Source string
+	// Seed is the seed passed to the program or -1 if it takes no seed.
+	Seed int64
+	// Images holds the filenames of the PNG images the program wrote.
+	Images   []string
+	Duration time.Duration
+	Err      error
+}
+
+// generatorFlag matches the declaration of the -seed and -size flags gallery
+// generators may define.
+var generatorFlag = regexp.MustCompile(`flag\.\w+\("(seed|size)"`)
+
+// cmdGallery implements the gallery subcommand, which runs the generative art
+// programs at reduced size with a fixed seed and writes a gallery of their images.
+// Only generators declaring a -seed or -size flag are run, which leaves out the
+// interactive image servers.
+func cmdGallery(args []string) error {
+	flags := flag.NewFlagSet("gallery", flag.ExitOnError)
+	out := flags.String("o", "gallery", "directory where the gallery document and images are written")
+	seed := flags.Int64("seed", 1, "seed passed to the programs")
+	size := flags.Int("size", 256, "image size in pixels passed to the programs")
+	var limits Limits
+	flags.DurationVar(&limits.Timeout, "timeout", time.Minute, "wall time limit of each program build and run, 0 means no limit")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tagalong gallery [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q", flags.Args())
+	}
+	vignettes, err := ParseDirExercises(".")
+	if err != nil {
+		return err
+	}
+	var generators []Vignette
+	for _, v := range vignettes {
+		if !v.IsGenerator() || v.Programs[LangGo.Ext] == "" {
+			continue
+		}
+		if !generatorFlag.MatchString(v.Programs[LangGo.Ext]) {
+			log.Printf("%s declares no -seed or -size flag, skipping", v.Code())
+			continue
+		}
+		generators = append(generators, v)
+	}
+	err = os.MkdirAll(*out, 0o755)
+	if err != nil {
+		return err
+	}
+	tmpdir, err := os.MkdirTemp("", "decaf-gallery")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+	entries := make([]GalleryEntry, len(generators))
+	parallel(len(generators), func(i int) {
+		entries[i] = generators[i].RenderImages(tmpdir, *out, limits, *seed, *size)
+	})
+	fp, err := os.Create(filepath.Join(*out, GalleryFilename))
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	failed := 0
+	for _, r := range entries {
+		if r.Err != nil {
+			log.Printf("%s: %v", r.Code(), r.Err)
+			failed++
+		}
+	}
+	err = WriteGallery(fp, *out, entries, *size)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d generators failed", failed)
+	}
+	return nil
+}
+
+// RenderImages builds the vignette's Go generator in dir and runs it in its own
+// working directory under limits, passing seed and size to the flags it declares.
+// The PNG images it writes are copied to out prefixed with the vignette code.
+func (v *Vignette) RenderImages(dir, out string, limits Limits, seed int64, size int) GalleryEntry {
+	r := GalleryEntry{Header: v.Header, Source: filepath.Join(v.Code(), v.Name+"."+LangGo.Ext), Seed: -1}
+	workdir := filepath.Join(dir, v.Code())
+	r.Err = os.Mkdir(workdir, 0o755)
+	if r.Err != nil {
+		return r
+	}
+	binary := filepath.Join(workdir, v.Name)
+	r.Err = runCommand(limits, "", "go", "build", "-o", binary, "./"+v.Code())
+	if r.Err != nil {
+		r.Err = fmt.Errorf("build: %w", r.Err)
+		return r
+	}
+	var args []string
+	for _, m := range generatorFlag.FindAllStringSubmatch(v.Programs[LangGo.Ext], -1) {
+		switch m[1] {
+		case "seed":
+			args = append(args, "-seed", strconv.FormatInt(seed, 10))
+			r.Seed = seed
+		case "size":
+			args = append(args, "-size", strconv.Itoa(size))
+		}
+	}
+	start := time.Now()
+	r.Err = runCommand(limits, workdir, binary, args...)
+	r.Duration = time.Since(start)
+	if r.Err != nil {
+		return r
+	}
+	pngs, err := filepath.Glob(filepath.Join(workdir, "*.png"))
+	if err != nil || len(pngs) == 0 {
+		r.Err = errors.New("no PNG images written")
+		return r
+	}
+	sort.Strings(pngs)
+	for _, png := range pngs {
+		image := v.Code() + "-" + filepath.Base(png)
+		b, err := os.ReadFile(png)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(out, image), b, 0o644)
+		}
+		if err != nil {
+			r.Err = err
+			return r
+		}
+		r.Images = append(r.Images, image)
+	}
+	return r
+}
+
+// runCommand runs name with args in dir under limits. The returned error
+// includes the command output if it fails.
+func runCommand(limits Limits, dir, name string, args ...string) error {
+	cmd, err := limits.command(name, args)
+	if err != nil {
+		return err
+	}
+	cmd.Dir = dir
+	var output lockedBuffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = limits.run(cmd, &output)
+	if err != nil && output.String() != "" {
+		return fmt.Errorf("%w\n%s", err, strings.TrimSuffix(output.String(), "\n"))
+	}
+	return err
+}
+
+// WriteGallery writes the markdown gallery document of the successful entries
+// to w. Links to program sources are made relative to the document directory dir.
+func WriteGallery(w io.Writer, dir string, entries []GalleryEntry, size int) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "# Gallery\nGenerative art programs rendered at %d pixels. Regenerate the gallery with `go run . gallery`.\n\n", size)
+	for _, r := range entries {
+		if r.Err != nil {
+			continue
+		}
+		source, err := filepath.Abs(r.Source)
+		if err == nil {
+			source, err = filepath.Rel(absDir, source)
+		}
+		if err != nil {
+			return err
+		}
+		source = filepath.ToSlash(source)
+		fmt.Fprintf(w, "## %s\n", r.Name)
+		for _, image := range r.Images {
+			fmt.Fprintf(w, "[![%s](%s)](%s)\n", image, image, image)
+		}
+		seed := "no seed"
+		if r.Seed >= 0 {
+			seed = fmt.Sprintf("seed %d", r.Seed)
+		}
+		fmt.Fprintf(w, "\nRendered with %s in %s from [%s](%s).\n\n", seed, r.Duration.Round(time.Millisecond), filepath.Base(r.Source), source)
+	}
+	return nil
+}
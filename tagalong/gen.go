@@ -1,20 +1,83 @@
 package main
 
 import (
+	"bytes"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
+	"text/tabwriter"
+	"time"
 )
 
+// subcommands maps subcommand names to their implementation, which receives
+// the command line arguments following the subcommand name.
+var subcommands = map[string]func(args []string) error{
+	"new":        cmdNew,
+	"renumber":   cmdRenumber,
+	"lint":       cmdLint,
+	"gallery":    cmdGallery,
+	"serve":      cmdServe,
+	"playground": cmdPlayground,
+	"check":      cmdCheck,
+	"progress":   cmdProgress,
+}
+
+// errUsage is wrapped by the errors of subcommands given invalid arguments,
+// which print their usage beforehand. main exits with status 2 for them,
+// like the flag package does for invalid flags.
+var errUsage = errors.New("invalid arguments")
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			err := cmd(os.Args[2:])
+			if errors.Is(err, errUsage) {
+				log.Print(err)
+				os.Exit(2)
+			} else if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags]\n       %s <new|renumber|lint|gallery|serve|playground|check|progress> [flags] [args]\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	verify := flag.Bool("verify", false, "run Python programs and report vignettes whose output differs from Go's")
+	normalize := flag.Bool("normalize", false, "ignore known Python/Go formatting differences when verifying, i.e: True vs true")
+	determinism := flag.Int("determinism", 0, "run each Go program again the given number of times and report vignettes whose output differs from the first run")
+	check := flag.Bool("check", false, "do not write documents, instead report stale documents and exit with non-zero status if any are found")
+	var limits Limits
+	flag.DurationVar(&limits.Timeout, "timeout", time.Minute, "wall time limit of each program run, 0 means no limit")
+	flag.DurationVar(&limits.CPUTime, "cpulimit", 0, "CPU time limit of each process started by a program run, 0 means no limit")
+	memlimit := flag.Int64("memlimit", 0, "address space limit in MiB of each process started by a program run, 0 means no limit")
+	cacheDir := flag.String("cache", DefaultCacheDir(), "directory where program results are cached")
+	buildDir := flag.String("builddir", DefaultBuildDir(), "directory where compiled programs are cached")
+	noCache := flag.Bool("nocache", false, "run every program, bypassing the cache")
+	clearCache := flag.Bool("clearcache", false, "remove cached program results and executables before running")
+	htmlDir := flag.String("html", "", "also write a static HTML site to the given directory")
+	book := flag.String("book", "", "also write the tagalong as an mdBook with one page per vignette to the given directory")
+	notebook := flag.String("ipynb", "", "also write a Jupyter notebook with the Python and Go programs and their outputs to the given file")
+	bench := flag.Int("bench", 0, "run the Go and Python programs of each vignette the given number of times and append a performance comparison table to the documents")
+	keepGoing := flag.Bool("keep-going", false, "generate documents even if vignette programs fail")
+	strict := flag.Bool("strict", false, "report every problem found in vignette directories and exit with non-zero status if any are found")
+	flag.Parse()
+	limits.AddressSpace = *memlimit << 20
+	if *strict {
+		err := ValidateDir(".")
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 	vignettes, err := ParseDirExercises(".")
 	if err != nil {
 		log.Fatal(err)
@@ -23,64 +86,221 @@ func main() {
 	if err != nil && !os.IsExist(err) {
 		log.Fatal(err)
 	}
+	executor := NewExecutor(tmpdir, *buildDir, limits)
+	if !*noCache {
+		executor.Cache = &Cache{Dir: *cacheDir}
+	}
+	if *clearCache {
+		err = (&Cache{Dir: *cacheDir}).Clear()
+		if err == nil {
+			err = executor.Builds.Clear()
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 	// We first generate the output of the Vignettes concurrently.
-	outputs := make([]string, len(vignettes))
-	var wg sync.WaitGroup
-	for i, vignette := range vignettes {
-		if vignette.Go == "" || vignette.MD == "" {
-			continue
+	results := executor.ExecuteGoVignettes(vignettes)
+	failed := PrintSummary(os.Stderr, vignettes, results)
+	if !*verify {
+		// Verifying logs the statistics once the Python programs ran too.
+		executor.LogCacheStats()
+	}
+	err = executor.Builds.Prune(maxBuildAge)
+	if err != nil {
+		log.Println("pruning build cache:", err)
+	}
+	if failed > 0 && !*keepGoing {
+		log.Fatalf("%d vignettes failed, use -keep-going to generate documents regardless", failed)
+	}
+	err = ExpandREADMEs(vignettes, results)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *determinism > 0 {
+		divergences := VerifyDeterminism(executor, vignettes, results, *determinism)
+		declared := make(map[Header]bool)
+		for _, v := range vignettes {
+			declared[v.Header] = v.Meta.Nondeterministic
 		}
-		wg.Add(1)
-		i := i
-		vignette := vignette
-		go func() {
-			outputs[i], err = vignette.ExecuteGo(tmpdir)
-			if err != nil {
-				log.Println("processing vignette", vignette.Name, err)
+		unexpected := 0
+		for _, d := range divergences {
+			switch {
+			case d.Err != nil:
+				fmt.Printf("%s: rerunning: %v\n", d.Code(), d.Err)
+				unexpected++
+			case declared[d.Header]:
+				fmt.Printf("%s: output varies between runs as declared\n", d.Code())
+			default:
+				fmt.Printf("%s: output varies between runs, declare `nondeterministic: true` in its metadata if intended\n%s\n", d.Code(), d.Diff)
+				unexpected++
+			}
+		}
+		if unexpected > 0 {
+			log.Fatalf("%d vignettes with nondeterministic output", unexpected)
+		}
+		return
+	}
+	if *verify {
+		divergences := VerifyPython(executor, vignettes, results, *normalize)
+		executor.LogCacheStats()
+		for _, d := range divergences {
+			if d.Err != nil {
+				fmt.Printf("%s: running Python: %v\n", d.Code(), d.Err)
+				continue
+			}
+			fmt.Printf("%s: output differs\n%s\n", d.Code(), d.Diff)
+		}
+		if len(divergences) > 0 {
+			log.Fatalf("%d vignettes diverge", len(divergences))
+		}
+		return
+	}
+
+	if *check {
+		stale := 0
+		for _, doc := range Documents {
+			diff, err := doc.Check(".", vignettes, results)
+			if errors.Is(err, fs.ErrNotExist) {
+				log.Printf("%s not found, skipping check", doc.Filename)
+				continue
+			} else if err != nil {
+				log.Fatal(err)
 			}
-			wg.Done()
-		}()
+			if diff != "" {
+				fmt.Print(diff)
+				stale++
+			}
+		}
+		if stale > 0 {
+			log.Fatalf("%d stale documents, run `go run .` to regenerate them", stale)
+		}
+		return
 	}
-	wg.Wait()
 
+	var benchmarks []map[string]Benchmark
+	if *bench > 0 {
+		benchmarks = executor.BenchVignettes(vignettes, *bench)
+	}
 	// Generate markdown files.
-	withZig, err := os.Create("tagalong_w_zig.md")
-	if err != nil {
-		log.Fatal(err)
+	for _, doc := range Documents {
+		fp, err := os.Create(doc.Filename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		doc.Render(fp, vignettes, results)
+		if benchmarks != nil {
+			WriteBenchmarks(fp, vignettes, benchmarks, *bench)
+		}
+		fp.Close()
 	}
-	tagalong, _ := os.Create("tagalong.md")
-	tagalongCodeOnly, _ := os.Create("tagalongCode.md")
-	defer withZig.Close()
-	const codeLevel = "###"
-	for i, vig := range vignettes {
-		if vig.MD == "" {
-			continue
+	if *book != "" {
+		err = WriteBook(*book, vignettes, results, benchmarks, *bench)
+		if err != nil {
+			log.Fatal(err)
 		}
-		fmt.Fprintf(withZig, "%s\n", vig.MD)
-		fmt.Fprintf(tagalong, "%s\n", vig.MD)
-		if vig.Go == "" || vig.Python == "" {
-			continue
+	}
+	if *htmlDir != "" {
+		err = WriteSite(*htmlDir, vignettes, results)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *notebook != "" {
+		langResults := make(map[string][]Result)
+		for _, lang := range Languages {
+			if lang.Optional {
+				continue
+			} else if lang.Ext == LangGo.Ext {
+				langResults[lang.Ext] = results
+			} else {
+				langResults[lang.Ext] = executor.ExecuteVignettes(vignettes, lang)
+			}
+		}
+		err = WriteNotebook(*notebook, vignettes, langResults)
+		if err != nil {
+			log.Fatal(err)
 		}
-		output := strings.TrimSuffix(outputs[i], "\n")
+	}
+}
+
+// ExecuteGoVignettes runs the Go program of every vignette with a README
+// using a bounded number of workers and returns the results.
+func (e *Executor) ExecuteGoVignettes(vignettes []Vignette) []Result {
+	return e.ExecuteVignettes(vignettes, LangGo)
+}
 
-		fmt.Fprintf(withZig, codeLevel+" Python (%s)\n```python\n%s\n```\n", vig.Name, vig.Python)
-		fmt.Fprintf(withZig, codeLevel+" Go (%s)\n```go\n%s\n```\n", vig.Name, vig.Go)
-		fmt.Fprintf(withZig, "**Output**:\n```plaintext\n%s\n```\n\n", output)
+// ExecuteVignettes runs the lang program of every vignette with a README
+// using a bounded number of workers and returns the results. Programs
+// which exceed their limits have the failure appended to their output.
+// If the executor builds lang programs, those without a cached result are
+// all built before any is run.
+func (e *Executor) ExecuteVignettes(vignettes []Vignette, lang Language) []Result {
+	results := make([]Result, len(vignettes))
+	cached := make([]bool, len(vignettes))
+	if e.Cache != nil {
+		parallel(len(vignettes), func(i int) {
+			if vignettes[i].HasProgram(lang.Ext) && vignettes[i].MD != "" {
+				results[i], cached[i] = e.Cache.Get(&vignettes[i], lang)
+			}
+		})
+	}
+	if e.Builds != nil && len(lang.Build) > 0 {
+		var uncached []Vignette
+		for i := range vignettes {
+			if !cached[i] {
+				uncached = append(uncached, vignettes[i])
+			}
+		}
+		e.Builds.BuildVignettes(e.Dir, uncached, lang, e.Limits)
+	}
+	parallel(len(vignettes), func(i int) {
+		if cached[i] || !vignettes[i].HasProgram(lang.Ext) || vignettes[i].MD == "" {
+			return
+		}
+		results[i] = e.run(&vignettes[i], lang)
+		if exceededLimit(results[i].Err) {
+			msg := "tagalong: " + results[i].Err.Error() + "\n"
+			results[i].Output = strings.TrimSuffix(results[i].Output, "\n") + "\n" + msg
+			results[i].Stderr += msg
+		}
+	})
+	return results
+}
 
-		fmt.Fprintf(tagalong, codeLevel+" Python (%s)\n```python\n%s\n```\n", vig.Name, vig.Python)
-		fmt.Fprintf(tagalong, codeLevel+" Go (%s)\n```go\n%s\n```\n", vig.Name, vig.Go)
-		fmt.Fprintf(tagalong, "**Output**:\n```plaintext\n%s\n```\n\n", output)
+// runsGo reports whether the vignette's Go program is run when generating documents.
+func runsGo(v Vignette) bool {
+	return v.HasProgram(LangGo.Ext) && v.MD != ""
+}
 
-		fmt.Fprintf(tagalongCodeOnly, "\n# %s\n", vig.Name)
-		fmt.Fprintf(tagalongCodeOnly, codeLevel+" Python (%s)\n```python\n%s\n```\n", vig.Name, vig.Python)
-		fmt.Fprintf(tagalongCodeOnly, codeLevel+" Go (%s)\n```go\n%s\n```\n", vig.Name, vig.Go)
-		fmt.Fprintf(tagalongCodeOnly, "**Output**:\n```plaintext\n%s\n```\n\n", output)
-		if vig.Zig != "" {
-			fmt.Fprintf(withZig, codeLevel+" Zig (%s)\n```zig\n%s\n```\n", vig.Name, vig.Zig)
+// PrintSummary writes a table with the result of each vignette Go program run
+// to w and returns the number of failed programs.
+func PrintSummary(w io.Writer, vignettes []Vignette, results []Result) (failed int) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VIGNETTE\tEXIT\tDURATION\tSTATUS")
+	for i, vig := range vignettes {
+		if !runsGo(vig) {
+			continue
+		}
+		status := "ok"
+		if results[i].Err != nil {
+			status = "FAIL: " + results[i].Err.Error()
+			failed++
 		}
+		duration := results[i].Duration.Round(time.Millisecond).String()
+		if results[i].Cached {
+			duration = "cached"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", vig.Code(), results[i].ExitCode, duration, status)
 	}
+	tw.Flush()
+	return failed
 }
 
+// ExpectedFilename is the name of the file holding the expected output of
+// a vignette's Go program, checked by the package tests.
+const ExpectedFilename = "expected.txt"
+
 type Header struct {
 	Num  int
 	Name string
@@ -88,26 +308,115 @@ type Header struct {
 
 type Vignette struct {
 	Header
-	MD     string
-	Go     string
-	Python string
-	Zig    string
+	MD string
+	// Programs maps a language's file extension to the vignette's program in said language.
+	Programs map[string]string
+	// Modules maps a language's file extension to the vignette's multi-file
+	// program in said language, read from the vignette subdirectory named after
+	// the extension, i.e: "003-packages/go/".
+	Modules map[string][]File
+	Meta    Meta
+	// Exercise is the vignette's exercise or the zero value if it has none.
+	Exercise Exercise
 }
 
-func (v *Vignette) ExecuteGo(dir string) (string, error) {
-	tmpFilename := filepath.Join(dir, v.Name+".go")
-	fp, err := os.Create(tmpFilename)
-	if err != nil {
-		return "", err
+func (v *Vignette) ExecuteGo(dir string, limits Limits) Result {
+	return v.Execute(dir, LangGo, limits)
+}
+
+func (v *Vignette) ExecutePython(dir string, limits Limits) Result {
+	return v.Execute(dir, LangPython, limits)
+}
+
+// Execute runs the vignette's lang program in dir under limits. Multi-file
+// programs are copied into a new directory in dir and run from there.
+func (v *Vignette) Execute(dir string, lang Language, limits Limits) Result {
+	return v.execute(dir, lang, limits, "")
+}
+
+// execute runs the vignette's lang program like Execute. If executable is
+// not empty it is run instead of running the program from source.
+func (v *Vignette) execute(dir string, lang Language, limits Limits, executable string) (res Result) {
+	res.ExitCode = -1
+	var run []string
+	var workdir string
+	srcdir := dir // Directory the program source is written to.
+	if executable != "" {
+		run = []string{executable}
 	}
-	defer os.Remove(tmpFilename)
-	_, err = fp.WriteString(v.Go)
+	if files := v.Modules[lang.Ext]; len(files) > 0 {
+		if len(lang.RunModule) == 0 && executable == "" {
+			res.Err = errors.New(lang.Name + " multi-file programs can not be run")
+			return res
+		}
+		root, err := os.MkdirTemp(dir, v.Name+"-")
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		defer os.RemoveAll(root)
+		err = writeModule(root, files)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		workdir, srcdir = root, root
+		if executable == "" {
+			run = lang.RunModule
+		}
+	} else if executable == "" {
+		if len(lang.Run) == 0 {
+			res.Err = errors.New(lang.Name + " programs can not be run")
+			return res
+		}
+		tmpFilename := filepath.Join(dir, v.Name+"."+lang.Ext)
+		fp, err := os.Create(tmpFilename)
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		defer os.Remove(tmpFilename)
+		_, err = fp.WriteString(v.Programs[lang.Ext])
+		fp.Close()
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		run = append(lang.Run[:len(lang.Run):len(lang.Run)], tmpFilename)
+	}
+	args := append(run[1:len(run):len(run)], v.Meta.Args...)
+	cmd, err := limits.command(run[0], args)
 	if err != nil {
-		return "", err
+		res.Err = err
+		return res
 	}
-	fp.Close()
-	out, err := exec.Command("go", "run", tmpFilename).CombinedOutput()
-	return string(out), err
+	cmd.Dir = workdir
+	cmd.Env = append(append(os.Environ(), lang.Env...), v.Meta.Env...)
+	cmd.Stdin = strings.NewReader(v.Meta.Stdin)
+	var combined lockedBuffer
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = io.MultiWriter(&combined, &stdout)
+	cmd.Stderr = io.MultiWriter(&combined, &stderr)
+	start := time.Now()
+	res.Err = limits.run(cmd, &combined)
+	res.Duration = time.Since(start)
+	if cmd.ProcessState != nil {
+		res.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	// Go programs which exit with their declared exit code, i.e: on purpose
+	// by panicking, succeed. Programs killed by a signal or a limit never do.
+	if lang.Ext == LangGo.Ext && res.ExitCode >= 0 && !exceededLimit(res.Err) {
+		switch {
+		case res.ExitCode == v.Meta.Exit:
+			res.Err = nil
+		case v.Meta.Exit != 0:
+			res.Err = fmt.Errorf("%w %d, want %d", ErrExitCode, res.ExitCode, v.Meta.Exit)
+		}
+	}
+	res.Output = sanitizePaths(combined.String(), srcdir)
+	res.Stdout = sanitizePaths(stdout.String(), srcdir)
+	res.Stderr = sanitizePaths(stderr.String(), srcdir)
+	return res
 }
 
 func ParseDirExercises(dir string) ([]Vignette, error) {
@@ -118,17 +427,27 @@ func ParseDirExercises(dir string) ([]Vignette, error) {
 	vignettes := make([]Vignette, len(found))
 	for i := range vignettes {
 		vignettes[i].Header = found[i]
+		vignettes[i].Programs = make(map[string]string)
+		vignettes[i].Modules = make(map[string][]File)
 		subdir := filepath.Join(dir, vignettes[i].Code())
 		entries, err := os.ReadDir(subdir)
 		if err != nil {
 			return nil, err
 		}
+		metaFrom := "" // File the metadata was read from.
 		for _, entry := range entries {
 			filename := entry.Name()
+			path := filepath.Join(subdir, filename)
 			if entry.IsDir() {
+				if _, ok := LookupLanguage(filename); !ok {
+					continue
+				}
+				vignettes[i].Modules[filename], err = readModule(path)
+				if err != nil {
+					return nil, err
+				}
 				continue
 			}
-			path := filepath.Join(subdir, filename)
 			fp, err := os.Open(path)
 			if err != nil {
 				return nil, err
@@ -139,17 +458,54 @@ func ParseDirExercises(dir string) ([]Vignette, error) {
 			}
 			switch filename {
 			case "README.md":
-				vignettes[i].MD = string(b)
-			case vignettes[i].Name + ".zig":
-				vignettes[i].Zig = string(b)
-			case vignettes[i].Name + ".go":
-				vignettes[i].Go = string(b)
-			case vignettes[i].Name + ".py":
-				vignettes[i].Python = string(b)
+				frontMatter, md := splitFrontMatter(string(b))
+				vignettes[i].MD = md
+				if frontMatter == "" {
+					continue
+				}
+				if metaFrom != "" {
+					return nil, fmt.Errorf("%s: metadata is declared in both %s and the README front matter, keep one", subdir, metaFrom)
+				}
+				metaFrom = filename
+				vignettes[i].Meta, err = ParseMeta(frontMatter)
+				if err != nil {
+					return nil, fmt.Errorf("%s front matter: %w", path, err)
+				}
+				continue
+			case MetaFilename:
+				if metaFrom != "" {
+					return nil, fmt.Errorf("%s: metadata is declared in both %s and the README front matter, keep one", subdir, filename)
+				}
+				metaFrom = filename
+				vignettes[i].Meta, err = ParseMeta(string(b))
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", path, err)
+				}
+				continue
+			case ExerciseFilename:
+				vignettes[i].Exercise.Stub = string(b)
+				continue
+			case ExerciseTestFilename:
+				vignettes[i].Exercise.Tests = string(b)
+				continue
+			}
+			ext := strings.TrimPrefix(filename, vignettes[i].Name+".")
+			if _, ok := LookupLanguage(ext); ok && ext != filename {
+				vignettes[i].Programs[ext] = string(b)
+			}
+		}
+		// Rendering and running would otherwise pick different programs.
+		for ext := range vignettes[i].Modules {
+			if vignettes[i].Programs[ext] != "" {
+				return nil, fmt.Errorf("%s: both %s.%s and %s/ hold a program, remove one", subdir, vignettes[i].Name, ext, ext)
 			}
 		}
 	}
 	sort.Sort(ByFilenameNumber(vignettes))
+	err = validatePrerequisites(vignettes)
+	if err != nil {
+		return nil, err
+	}
 	return vignettes, nil
 }
 
@@ -168,15 +524,14 @@ func ParseDir(dir string) ([]Header, error) {
 		if !entry.IsDir() {
 			continue
 		}
-		path := filepath.Join(dir, filename)
-		exercise, err := ParseHeader(path)
+		exercise, err := ParseHeader(filename)
 		if err != nil {
-			continue
-			return nil, errors.New("parsing directory \"" + dir + "\": " + err.Error())
+			continue // Not a vignette, use ValidateDir to find malformed names.
 		}
 		found = append(found, exercise)
 	}
-	sort.Sort(ByHeaderNumber(found))
+	// Vignettes sharing a number keep the order of their names.
+	sort.Stable(ByHeaderNumber(found))
 
 	return found, nil
 }
@@ -191,6 +546,12 @@ func (f Header) Code() string {
 	return fmt.Sprintf("%03d-%s", f.Num, f.Name)
 }
 
+// IsGenerator reports whether the header belongs to a standalone generative
+// art program, which are numbered 900 and above. These need no README nor Python twin.
+func (f Header) IsGenerator() bool {
+	return f.Num >= 900
+}
+
 type ByHeaderNumber []Header
 
 func (a ByHeaderNumber) Len() int           { return len(a) }
@@ -198,8 +559,8 @@ func (a ByHeaderNumber) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ByHeaderNumber) Less(i, j int) bool { return a[i].Num < a[j].Num }
 
 func ParseHeader(code string) (Header, error) {
-	if len(code) < 8 {
-		return Header{}, errors.New(code + " exercise filename name must be more than 8 characters long")
+	if len(code) < 5 {
+		return Header{}, errors.New(code + " exercise filename name must be at least 5 characters long")
 	}
 	n, err := strconv.Atoi(code[0:3])
 	if err != nil {
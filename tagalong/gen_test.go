@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTagalongUpToDate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs every vignette")
+	}
+	vignettes, err := ParseDirExercises(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	executor := NewExecutor(dir, filepath.Join(dir, "build"), Limits{Timeout: time.Minute})
+	results := executor.ExecuteGoVignettes(vignettes)
+	for i, res := range results {
+		if res.Err != nil {
+			t.Errorf("%s: %v\n%s", vignettes[i].Code(), res.Err, res.Output)
+		}
+	}
+	err = ExpandREADMEs(vignettes, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range Documents {
+		if doc.Doc != DocTagalong {
+			continue // Only tagalong.md is committed.
+		}
+		diff, err := doc.Check(".", vignettes, results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff != "" {
+			t.Errorf("%s is stale, regenerate it with `go run .`:\n%s", doc.Filename, diff)
+		}
+	}
+}
+
+// TestRenderTOC checks that every link of the table of contents and of the
+// previous and next links points to an anchor in the document.
+func TestRenderTOC(t *testing.T) {
+	vignettes := []Vignette{
+		{Header: Header{Num: 1, Name: "intro"}, MD: "# Introduction\n"},
+		{Header: Header{Num: 2, Name: "hello"}, MD: "# Hello\n", Programs: map[string]string{"go": "package main\n", "py": "print('hi')\n"}},
+		{Header: Header{Num: 3, Name: "bye"}, MD: "# Bye\n", Programs: map[string]string{"go": "package main\n", "py": "print('bye')\n"}},
+		{Header: Header{Num: 901, Name: "art"}, Programs: map[string]string{"go": "package main\n"}},
+	}
+	results := make([]Result, len(vignettes))
+	for _, doc := range []Document{
+		{Doc: DocTagalong, README: true, TOC: true},
+		{Doc: DocCodeOnly, TOC: true},
+	} {
+		var buf bytes.Buffer
+		doc.Render(&buf, vignettes, results)
+		md := buf.String()
+		anchors := make(map[string]bool)
+		for _, m := range regexp.MustCompile(`<a id="([^"]+)"></a>`).FindAllStringSubmatch(md, -1) {
+			if anchors[m[1]] {
+				t.Errorf("doc %d: duplicate anchor %q", doc.Doc, m[1])
+			}
+			anchors[m[1]] = true
+		}
+		for _, m := range regexp.MustCompile(`\]\(#([^)]+)\)`).FindAllStringSubmatch(md, -1) {
+			if !anchors[m[1]] {
+				t.Errorf("doc %d: link to missing anchor %q", doc.Doc, m[1])
+			}
+		}
+		if anchors["001-intro"] != doc.README || anchors["901-art"] {
+			t.Errorf("doc %d: want sections only for vignettes rendered in the document, got anchors %v", doc.Doc, anchors)
+		}
+		if !strings.Contains(md, "\n[Contents](#contents) | [") {
+			t.Errorf("doc %d: first section has a previous link:\n%s", doc.Doc, md)
+		}
+		last := fmt.Sprintf("\n[&larr; %s](#002-hello) | [Contents](#contents)\n\n", doc.title(vignettes[1]))
+		if !strings.HasSuffix(md, last) {
+			t.Errorf("doc %d: last section has a next link:\n%s", doc.Doc, md)
+		}
+	}
+}
+
+func TestWriteOutput(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		res  Result
+		want string
+	}{
+		{
+			name: "success",
+			res:  Result{Output: "hi\n", Stdout: "hi\n"},
+			want: "**Output**:\n```plaintext\nhi\n```\n\n",
+		},
+		{
+			name: "exit code",
+			res:  Result{Output: "hi\noops\n", Stdout: "hi\n", Stderr: "oops\n", ExitCode: 2, Err: errors.New("exit status 2")},
+			want: "**Output**:\n```plaintext\nhi\n```\n\n**Standard error**:\n```plaintext\noops\n```\n\n**Exit code**: 2\n\n",
+		},
+		{
+			name: "signal",
+			res:  Result{ExitCode: -1, Err: errors.New("signal: killed")},
+			want: "**Output**:\n```plaintext\n\n```\n\n**Exit status**: signal: killed\n\n",
+		},
+		{
+			name: "timeout",
+			res:  Result{Output: "tick\n", Stdout: "tick\n", ExitCode: -1, Err: fmt.Errorf("%w after 1s", ErrTimeout)},
+			want: "**Output**:\n```plaintext\ntick\n```\n\n**Exit status**: timed out after 1s\n\n",
+		},
+		{
+			name: "compile error",
+			res:  Result{Output: "./x.go:1: bad\n", Stderr: "./x.go:1: bad\n", ExitCode: -1, Err: fmt.Errorf("%w: exit status 1", ErrBuild)},
+			want: "**Compile error**:\n```plaintext\n./x.go:1: bad\n```\n\n",
+		},
+	} {
+		var buf bytes.Buffer
+		writeOutput(&buf, test.res)
+		if got := buf.String(); got != test.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestExecuteExpectedExit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go")
+	}
+	const panics = "package main\n\nfunc main() { panic(\"boom\") }\n"
+	const succeeds = "package main\n\nfunc main() {}\n"
+	for _, test := range []struct {
+		name    string
+		program string
+		exit    int
+		wantErr error
+	}{
+		{name: "declared panic", program: panics, exit: 2},
+		{name: "undeclared panic", program: panics, wantErr: errors.New("exit status 2")},
+		{name: "other exit code", program: panics, exit: 3, wantErr: ErrExitCode},
+		{name: "declared panic missing", program: succeeds, exit: 2, wantErr: ErrExitCode},
+	} {
+		dir := t.TempDir()
+		executor := &Executor{Dir: dir, Limits: Limits{Timeout: time.Minute}, Cache: &Cache{Dir: filepath.Join(dir, "cache")}, Builds: &BuildCache{Dir: filepath.Join(dir, "build")}}
+		v := &Vignette{Header: Header{Num: 1, Name: "panic"}, Programs: map[string]string{"go": test.program}, Meta: Meta{Exit: test.exit}}
+		res := executor.Execute(v, LangGo)
+		switch {
+		case test.wantErr == nil && res.Err != nil:
+			t.Errorf("%s: got error %v, want success", test.name, res.Err)
+		case test.wantErr != nil && (res.Err == nil || !errors.Is(res.Err, test.wantErr) && res.Err.Error() != test.wantErr.Error()):
+			t.Errorf("%s: got error %v, want %v", test.name, res.Err, test.wantErr)
+		}
+		if test.program == panics && (res.ExitCode != 2 || !strings.Contains(res.Stderr, "panic: boom")) {
+			t.Errorf("%s: got exit code %d and standard error %q, want a panic", test.name, res.ExitCode, res.Stderr)
+		}
+		// Expected exits are cached like any successful run.
+		if cached := executor.Execute(v, LangGo).Cached; cached != (test.wantErr == nil) {
+			t.Errorf("%s: got cached %v on second run", test.name, cached)
+		}
+	}
+}
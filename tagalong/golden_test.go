@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the expected output file of every vignette")
+
+func TestVignettesGolden(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs every vignette")
+	}
+	vignettes, err := ParseDirExercises(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	executor := NewExecutor(dir, filepath.Join(dir, "build"), Limits{Timeout: time.Minute})
+	for i := range vignettes {
+		vig := &vignettes[i]
+		if !runsGo(*vig) {
+			continue
+		}
+		t.Run(vig.Code(), func(t *testing.T) {
+			t.Parallel()
+			res := executor.Execute(vig, LangGo)
+			if res.Err != nil {
+				t.Fatalf("%v\n%s", res.Err, res.Output)
+			}
+			if vig.Meta.Nondeterministic {
+				t.Skip("output varies between runs")
+			}
+			golden := filepath.Join(vig.Code(), ExpectedFilename)
+			if *update {
+				err := os.WriteFile(golden, []byte(res.Output), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if errors.Is(err, fs.ErrNotExist) {
+				t.Skip("no expected output file, create it with -update")
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(maskAddresses(want), maskAddresses([]byte(res.Output))) {
+				t.Errorf("output differs from %s, run `go test -run Golden -update` if the change is intended:\n%s",
+					golden, UnifiedDiff(golden, "output", string(want), res.Output))
+			}
+		})
+	}
+}
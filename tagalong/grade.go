@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// ExerciseFilename is the name of the optional exercise of a vignette, a Go
+	// program with TODO stubs for learners to complete.
+	ExerciseFilename = "exercise.go"
+	// ExerciseTestFilename is the name of the tests which grade a vignette's
+	// exercise. They are not shown in documents.
+	ExerciseTestFilename = "exercise_test.go"
+	// exerciseTag is the build constraint of exercise files, which keeps them
+	// out of the vignette package they share a directory with.
+	exerciseTag = "exercise"
+)
+
+// Exercise is a Go program with TODO stubs for learners to complete and the
+// tests which grade it.
+type Exercise struct {
+	Stub  string
+	Tests string
+}
+
+// TestResult is the outcome of a test grading an exercise.
+type TestResult struct {
+	Name string
+	// Action is "pass", "fail" or "skip".
+	Action string
+	// Output is the test's output without go test's own status lines.
+	Output string
+}
+
+// testEvent is a line of `go test -json` output.
+type testEvent struct {
+	Action string
+	Test   string
+	Output string
+}
+
+// Grade runs the exercise tests of the vignette against the Go source src of a
+// learner's solution in a new module in dir under limits. If the solution does
+// not compile the returned error wraps ErrBuild and output holds the compiler output.
+func (v *Vignette) Grade(dir, src string, limits Limits) (results []TestResult, output string, err error) {
+	if v.Exercise.Tests == "" {
+		return nil, "", errors.New(v.Code() + " has no exercise")
+	}
+	root, err := os.MkdirTemp(dir, v.Name+"-exercise-")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(root)
+	err = writeModule(root, []File{
+		{Path: "go.mod", Content: "module exercise\n\ngo 1.19\n"},
+		{Path: ExerciseFilename, Content: src},
+		{Path: ExerciseTestFilename, Content: v.Exercise.Tests},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	cmd, err := limits.command("go", []string{"test", "-tags", exerciseTag, "-json", "."})
+	if err != nil {
+		return nil, "", err
+	}
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), LangGo.Env...)
+	var stdout bytes.Buffer
+	var stderr lockedBuffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = limits.run(cmd, &stderr)
+	if exceededLimit(err) {
+		return nil, sanitizePaths(stderr.String(), root), err
+	}
+	var other strings.Builder // Output not attributed to a test, i.e: compile errors.
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		var event testEvent
+		if json.Unmarshal(scanner.Bytes(), &event) != nil {
+			other.WriteString(scanner.Text() + "\n")
+			continue
+		}
+		if event.Test == "" {
+			if event.Action == "build-output" {
+				other.WriteString(event.Output)
+			}
+			continue
+		}
+		i, ok := index[event.Test]
+		if !ok {
+			i = len(results)
+			index[event.Test] = i
+			results = append(results, TestResult{Name: event.Test})
+		}
+		switch event.Action {
+		case "pass", "fail", "skip":
+			results[i].Action = event.Action
+		case "output":
+			trimmed := strings.TrimSpace(event.Output)
+			if !strings.HasPrefix(trimmed, "=== ") && !strings.HasPrefix(trimmed, "--- ") {
+				results[i].Output += sanitizePaths(event.Output, root)
+			}
+		}
+	}
+	output = sanitizePaths(stderr.String()+other.String(), root)
+	if err != nil && len(results) == 0 {
+		return nil, output, fmt.Errorf("%w: %v", ErrBuild, err)
+	}
+	return results, output, nil
+}
+
+// passed reports whether there are tests and none of them failed.
+func passed(results []TestResult) bool {
+	for _, res := range results {
+		if res.Action != "pass" && res.Action != "skip" {
+			return false
+		}
+	}
+	return len(results) > 0
+}
+
+// Progress records the exercises a learner completed.
+type Progress struct {
+	// Completed maps vignette names to when their exercise was first passed.
+	// Names are used rather than codes so that renumbering keeps progress.
+	Completed map[string]time.Time `json:"completed"`
+}
+
+// DefaultProgressFile returns the default progress file in the user configuration directory.
+func DefaultProgressFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "tagalong", "progress.json")
+}
+
+// LoadProgress reads the progress file. A missing file means no progress.
+func LoadProgress(filename string) (*Progress, error) {
+	progress := &Progress{Completed: make(map[string]time.Time)}
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return progress, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, progress)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if progress.Completed == nil {
+		progress.Completed = make(map[string]time.Time)
+	}
+	return progress, nil
+}
+
+// Save writes the progress to filename, creating its directory if needed.
+func (p *Progress) Save(filename string) error {
+	b, err := json.MarshalIndent(p, "", "\t")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(filename), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(b, '\n'), 0o644)
+}
+
+// cmdCheck implements the check subcommand, which grades a learner's solution
+// to a vignette's exercise, reports the result of each test and records the
+// exercise as completed in the progress file if every test passes.
+func cmdCheck(args []string) error {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	progressFile := flags.String("progress", DefaultProgressFile(), "file where completed exercises are recorded")
+	var limits Limits
+	flags.DurationVar(&limits.Timeout, "timeout", time.Minute, "wall time limit of the tests, 0 means no limit")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tagalong check [flags] <vignette> <file>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("%w: want a vignette and a file, got %d arguments", errUsage, flags.NArg())
+	}
+	vignettes, err := ParseDirExercises(".")
+	if err != nil {
+		return err
+	}
+	v, err := lookupVignette(vignettes, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	src, err := os.ReadFile(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	tmpdir, err := os.MkdirTemp("", "decaf-check")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+	results, output, err := v.Grade(tmpdir, string(src), limits)
+	if output != "" {
+		fmt.Print(output)
+	}
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, res := range results {
+		fmt.Println(strings.ToUpper(res.Action), res.Name)
+		if res.Action == "fail" {
+			failed++
+			for _, line := range strings.SplitAfter(strings.TrimRight(res.Output, "\n"), "\n") {
+				fmt.Print("\t", strings.TrimLeft(line, " "))
+			}
+			fmt.Println()
+		}
+	}
+	if !passed(results) {
+		return fmt.Errorf("%s: %d of %d tests failed", v.Code(), failed, len(results))
+	}
+	progress, err := LoadProgress(*progressFile)
+	if err != nil {
+		return err
+	}
+	if _, done := progress.Completed[v.Name]; !done {
+		progress.Completed[v.Name] = time.Now().UTC().Truncate(time.Second)
+		err = progress.Save(*progressFile)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("%s: exercise complete\n", v.Code())
+	return nil
+}
+
+// cmdProgress implements the progress subcommand, which lists the vignettes
+// with exercises and which of them were completed.
+func cmdProgress(args []string) error {
+	flags := flag.NewFlagSet("progress", flag.ExitOnError)
+	progressFile := flags.String("progress", DefaultProgressFile(), "file where completed exercises are recorded")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tagalong progress [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	vignettes, err := ParseDirExercises(".")
+	if err != nil {
+		return err
+	}
+	progress, err := LoadProgress(*progressFile)
+	if err != nil {
+		return err
+	}
+	total, done := 0, 0
+	for _, v := range vignettes {
+		if v.Exercise.Tests == "" {
+			continue
+		}
+		total++
+		completed, ok := progress.Completed[v.Name]
+		if !ok {
+			fmt.Printf("[ ] %s\n", v.Code())
+			continue
+		}
+		done++
+		fmt.Printf("[x] %s (completed %s)\n", v.Code(), completed.Local().Format("2006-01-02"))
+	}
+	fmt.Printf("%d of %d exercises complete\n", done, total)
+	return nil
+}
+
+// lookupVignette returns the vignette whose name or code is name.
+func lookupVignette(vignettes []Vignette, name string) (*Vignette, error) {
+	for i := range vignettes {
+		if vignettes[i].Name == name || vignettes[i].Code() == name {
+			return &vignettes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no vignette named %q", name)
+}
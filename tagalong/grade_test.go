@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExercises(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the tests of every exercise")
+	}
+	vignettes, err := ParseDirExercises(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits := Limits{Timeout: time.Minute}
+	for i := range vignettes {
+		vig := &vignettes[i]
+		if vig.Exercise.Tests == "" {
+			continue
+		}
+		t.Run(vig.Code(), func(t *testing.T) {
+			t.Parallel()
+			if vig.Exercise.Stub == "" {
+				t.Fatalf("exercise tests without %s", ExerciseFilename)
+			}
+			results, output, err := vig.Grade(t.TempDir(), vig.Exercise.Stub, limits)
+			if err != nil {
+				t.Fatalf("grading stub: %v\n%s", err, output)
+			}
+			if passed(results) {
+				t.Errorf("stub %s passes the exercise tests", ExerciseFilename)
+			}
+			results, output, err = vig.Grade(t.TempDir(), vig.Programs[LangGo.Ext], limits)
+			if err != nil {
+				t.Fatalf("grading vignette program: %v\n%s", err, output)
+			}
+			for _, res := range results {
+				if res.Action == "fail" {
+					t.Errorf("vignette program fails %s:\n%s", res.Name, res.Output)
+				}
+			}
+		})
+	}
+}
+
+func TestCmdCheckUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"004-funcadd"}, {"004-funcadd", "a.go", "b.go"}} {
+		err := cmdCheck(args)
+		if !errors.Is(err, errUsage) {
+			t.Errorf("check %q: got error %v, want usage error", args, err)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	_ "embed"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed tagalong.css
+var siteCSS string
+
+var sitePage = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - Tagalong</title>
+<link rel="stylesheet" href="tagalong.css">
+</head>
+<body>
+{{define "pager"}}<nav class="pager">
+<span>{{with .Prev}}<a href="{{.Href}}" rel="prev">&larr; {{.Title}}</a>{{end}}</span>
+<a href="index.html">Index</a>
+<span>{{with .Next}}<a href="{{.Href}}" rel="next">{{.Title}} &rarr;</a>{{end}}</span>
+</nav>{{end}}{{template "pager" .}}
+<main>
+<article class="readme">
+{{.README}}
+{{- with .Meta}}{{if or .Difficulty .Tags .Prerequisites}}
+<dl class="meta">
+{{- with .Difficulty}}<dt>Difficulty</dt><dd>{{.}}</dd>{{end}}
+{{- with .Tags}}<dt>Tags</dt><dd>{{range $i, $t := .}}{{if $i}}, {{end}}{{$t}}{{end}}</dd>{{end}}
+{{- with $.Prerequisites}}<dt>Prerequisites</dt><dd>{{range $i, $p := .}}{{if $i}}, {{end}}<a href="{{$p.Href}}">{{$p.Title}}</a>{{end}}</dd>{{end}}
+</dl>
+{{- end}}{{end}}
+</article>
+{{- if .Programs}}
+{{range .Optional}}<input type="checkbox" class="toggle" id="toggle-{{.Fence}}"><label for="toggle-{{.Fence}}">Show {{.Name}}</label>
+{{end}}<div class="programs">
+{{- range .Programs}}
+<section class="program{{if .Optional}} optional{{end}}">
+<h3>{{.Lang}}</h3>
+{{- range .Files}}
+<figure>
+<figcaption>{{.Path}}</figcaption>
+<pre><code class="language-{{.Fence}}">{{.Content}}</code></pre>
+</figure>
+{{- end}}
+</section>
+{{- end}}
+</div>
+{{- with .Meta.Args}}
+<p class="args">Arguments: <code>{{range $i, $a := .}}{{if $i}} {{end}}{{$a}}{{end}}</code></p>
+{{- end}}
+{{- with .Meta.Stdin}}
+<section class="output">
+<h3>Input</h3>
+<pre>{{.}}</pre>
+</section>
+{{- end}}
+<section class="output">
+{{- if .Meta.Nondeterministic}}
+<p class="meta">The output of this program varies between runs.</p>
+{{- end}}
+{{- range .Output}}
+<h3>{{.Label}}</h3>
+<pre>{{.Text}}</pre>
+{{- end}}
+{{- with .ExitStatus}}
+<p class="meta">{{.}}</p>
+{{- end}}
+</section>
+{{- end}}
+</main>
+{{template "pager" .}}
+</body>
+</html>
+`))
+
+var siteIndex = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Tagalong</title>
+<link rel="stylesheet" href="tagalong.css">
+</head>
+<body>
+<main>
+<h1>Tagalong</h1>
+<ol class="index">
+{{- range .}}
+<li value="{{.Num}}"><a href="{{.Href}}">{{.Title}}</a></li>
+{{- end}}
+</ol>
+</main>
+</body>
+</html>
+`))
+
+type sitePageLink struct {
+	Num   int
+	Title string
+	Href  string
+}
+
+type siteProgram struct {
+	Lang     string
+	Files    []siteFile
+	Optional bool
+}
+
+type siteFile struct {
+	Path    string
+	Fence   string
+	Content string
+}
+
+type sitePageData struct {
+	Title         string
+	README        template.HTML
+	Meta          Meta
+	Prerequisites []sitePageLink
+	Programs      []siteProgram
+	Optional      []Language // Optional languages with programs in the page.
+	Output        []outputBlock
+	ExitStatus    string
+	Prev, Next    *sitePageLink
+}
+
+// WriteSite writes a static HTML site to dir with one page per vignette, with
+// its programs side by side, and an index page. results holds the Go program
+// result of each vignette.
+func WriteSite(dir string, vignettes []Vignette, results []Result) error {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(dir, "tagalong.css"), []byte(siteCSS), 0o644)
+	if err != nil {
+		return err
+	}
+	var links []sitePageLink
+	var pages []int // Index of the vignette of each page.
+	linkByName := make(map[string]sitePageLink)
+	hrefs := make(map[string]string) // Page of each vignette by code.
+	for i, vig := range vignettes {
+		if vig.MD == "" {
+			continue
+		}
+		links = append(links, sitePageLink{Num: vig.Num, Title: vignetteTitle(vig), Href: vig.Code() + ".html"})
+		pages = append(pages, i)
+		linkByName[vig.Name] = links[len(links)-1]
+		hrefs[vig.Code()] = links[len(links)-1].Href
+	}
+	for p, i := range pages {
+		vig := vignettes[i]
+		data := sitePageData{
+			Title:  links[p].Title,
+			README: MarkdownHTML(string(rewriteVignetteLinks([]byte(vig.MD), hrefs))),
+			Meta:   vig.Meta,
+			Output: outputBlocks(results[i]),
+		}
+		if label, status := exitStatus(results[i]); label != "" {
+			data.ExitStatus = label + ": " + status
+		}
+		for _, prereq := range vig.Meta.Prerequisites {
+			data.Prerequisites = append(data.Prerequisites, linkByName[prereq])
+		}
+		if p > 0 {
+			data.Prev = &links[p-1]
+		}
+		if p < len(pages)-1 {
+			data.Next = &links[p+1]
+		}
+		if vig.HasProgram(LangGo.Ext) {
+			for _, lang := range Languages {
+				if !vig.HasProgram(lang.Ext) {
+					continue
+				}
+				program := siteProgram{Lang: lang.Name, Optional: lang.Optional}
+				for _, file := range vig.ProgramFiles(lang.Ext) {
+					program.Files = append(program.Files, siteFile{Path: file.Path, Fence: fileFence(file), Content: file.Content})
+				}
+				data.Programs = append(data.Programs, program)
+				if lang.Optional {
+					data.Optional = append(data.Optional, lang)
+				}
+			}
+		}
+		err = writeTemplate(filepath.Join(dir, links[p].Href), sitePage, data)
+		if err != nil {
+			return err
+		}
+	}
+	return writeTemplate(filepath.Join(dir, "index.html"), siteIndex, links)
+}
+
+func writeTemplate(filename string, t *template.Template, data any) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = t.Execute(fp, data)
+	if err != nil {
+		fp.Close()
+		return err
+	}
+	return fp.Close()
+}
+
+// vignetteTitle returns the vignette's title from its metadata, the text of
+// the first heading of its README or its name, whichever is found first.
+func vignetteTitle(v Vignette) string {
+	if v.Meta.Title != "" {
+		return v.Meta.Title
+	}
+	for _, line := range strings.Split(v.MD, "\n") {
+		if m := mdHeading.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			return m[2]
+		}
+	}
+	return v.Name
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Language describes a programming language vignette programs may be written in.
+type Language struct {
+	// Name is the display name of the language, i.e: "Python".
+	Name string
+	// Ext is the file extension of the language's programs without the leading dot.
+	// Vignette programs are named after the vignette, i.e: "hello.py".
+	Ext string
+	// Fence is the info string of the language's markdown code blocks.
+	Fence string
+	// Comment starts a line comment, used to find region markers in programs.
+	Comment string
+	// Run is the command used to run a program. The program's filename is
+	// appended as the last argument. Languages with no Run command are not executed.
+	Run []string
+	// RunModule is the command used to run a multi-file program from its root directory.
+	RunModule []string
+	// Build is the command used to compile a program into an executable, run from
+	// the program's directory. The executable filename is appended followed by the
+	// program's filename or "." for multi-file programs. Languages with no Build
+	// command are interpreted.
+	Build []string
+	// Env holds extra environment variables in the form "key=value" set when running programs.
+	Env []string
+	// Version is the command which prints the toolchain version. Program
+	// results are cached per toolchain version.
+	Version []string
+	// CacheEnv holds the names of environment variables which affect program
+	// results. Program results are cached per value of these variables.
+	CacheEnv []string
+	// Template is the program created for new vignettes.
+	Template string
+	// Docs is the set of output documents that include programs in this language.
+	Docs Doc
+	// Optional languages are not required for a vignette's code to be rendered
+	// and are rendered after the program output.
+	Optional bool
+}
+
+var (
+	LangPython = Language{
+		Name: "Python", Ext: "py", Fence: "python", Comment: "#", Docs: DocAll,
+		Run:       []string{"python3"},
+		RunModule: []string{"python3", "main.py"},
+		Version:   []string{"python3", "--version"},
+		CacheEnv:  []string{"PYTHONHASHSEED", "PYTHONPATH"},
+		Template:  "print(\"Hello, world!\")\n",
+	}
+	LangGo = Language{
+		Name: "Go", Ext: "go", Fence: "go", Comment: "//", Docs: DocAll,
+		Run:       []string{"go", "run"},
+		RunModule: []string{"go", "run", "."},
+		Build:     []string{"go", "build", "-trimpath", "-o"},
+		// Go programs run with a deterministic math/rand global source, like on the Go playground.
+		Env:      []string{"GODEBUG=randautoseed=0"},
+		Version:  []string{"go", "version"},
+		CacheEnv: []string{"GOOS", "GOARCH", "GOFLAGS", "GODEBUG", "GOEXPERIMENT", "GOAMD64", "GOARM"},
+		Template: "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"Hello, world!\")\n}\n",
+	}
+	LangZig = Language{
+		Name: "Zig", Ext: "zig", Fence: "zig", Comment: "//", Docs: DocZig, Optional: true,
+		Run:     []string{"zig", "run"},
+		Version: []string{"zig", "version"},
+	}
+)
+
+// Languages is the language registry. Programs are rendered in registry order.
+var Languages = []Language{LangPython, LangGo, LangZig}
+
+// RegisterLanguage adds lang to the language registry.
+func RegisterLanguage(lang Language) {
+	Languages = append(Languages, lang)
+}
+
+// LookupLanguage returns the registered language with file extension ext.
+func LookupLanguage(ext string) (Language, bool) {
+	for _, lang := range Languages {
+		if lang.Ext == ext {
+			return lang, true
+		}
+	}
+	return Language{}, false
+}
+
+// Doc is a set of output documents.
+type Doc uint8
+
+const (
+	DocTagalong Doc = 1 << iota // tagalong.md
+	DocCodeOnly                 // tagalongCode.md
+	DocZig                      // tagalong_w_zig.md
+
+	DocAll = DocTagalong | DocCodeOnly | DocZig
+)
+
+// Document is a markdown output document.
+type Document struct {
+	Doc      Doc
+	Filename string
+	// README is set if vignette READMEs are rendered. Otherwise
+	// only the vignette name is rendered as a heading before the code.
+	README bool
+	// TOC is set if a table of contents linking to the anchor of each vignette
+	// section is rendered at the top and links to the previous and next
+	// sections at the end of each section.
+	TOC bool
+}
+
+var Documents = []Document{
+	{Doc: DocZig, Filename: "tagalong_w_zig.md", README: true, TOC: true},
+	{Doc: DocTagalong, Filename: "tagalong.md", README: true, TOC: true},
+	{Doc: DocCodeOnly, Filename: "tagalongCode.md", TOC: true},
+}
+
+// Render writes the document to w. results holds the Go program result of each vignette.
+func (d Document) Render(w io.Writer, vignettes []Vignette, results []Result) {
+	var sections []int // Index of the vignette of each section.
+	for i, vig := range vignettes {
+		if vig.MD != "" && (d.README || d.hasRequiredCode(vig)) {
+			sections = append(sections, i)
+		}
+	}
+	if d.TOC {
+		fmt.Fprint(w, "<a id=\"contents\"></a>\n\n## Contents\n")
+		for _, i := range sections {
+			fmt.Fprintf(w, "- [%s](#%s)\n", d.title(vignettes[i]), vignettes[i].Code())
+		}
+		fmt.Fprintln(w)
+	}
+	for s, i := range sections {
+		if !d.TOC {
+			d.renderVignette(w, vignettes[i], results[i])
+			continue
+		}
+		var section bytes.Buffer
+		d.renderVignette(&section, vignettes[i], results[i])
+		links := make([]string, 0, 3)
+		if s > 0 {
+			prev := vignettes[sections[s-1]]
+			links = append(links, fmt.Sprintf("[&larr; %s](#%s)", d.title(prev), prev.Code()))
+		}
+		links = append(links, "[Contents](#contents)")
+		if s < len(sections)-1 {
+			next := vignettes[sections[s+1]]
+			links = append(links, fmt.Sprintf("[%s &rarr;](#%s)", d.title(next), next.Code()))
+		}
+		fmt.Fprintf(w, "<a id=\"%s\"></a>\n\n%s\n\n%s\n\n", vignettes[i].Code(), bytes.Trim(section.Bytes(), "\n"), strings.Join(links, " | "))
+	}
+}
+
+// title returns the title of the vignette's section in the document.
+func (d Document) title(vig Vignette) string {
+	if d.README {
+		return vignetteTitle(vig)
+	} else if vig.Meta.Title != "" {
+		return vig.Meta.Title
+	}
+	return vig.Name
+}
+
+// renderVignette writes the section of the vignette to w. res is the result of its Go program.
+func (d Document) renderVignette(w io.Writer, vig Vignette, res Result) {
+	const codeLevel = "###"
+	if d.README {
+		fmt.Fprintf(w, "%s\n", vig.MD)
+		if summary := vig.Meta.summary(); summary != "" {
+			fmt.Fprintf(w, "*%s.*\n\n", summary)
+		}
+	}
+	if !d.hasRequiredCode(vig) {
+		return
+	}
+	if !d.README {
+		fmt.Fprintf(w, "\n# %s\n", d.title(vig))
+	}
+	writeCode := func(optional bool) {
+		for _, lang := range Languages {
+			if lang.Optional != optional || lang.Docs&d.Doc == 0 || !vig.HasProgram(lang.Ext) {
+				continue
+			}
+			if vig.Programs[lang.Ext] != "" {
+				fmt.Fprintf(w, codeLevel+" %s (%s)\n```%s\n%s\n```\n", lang.Name, vig.Name, lang.Fence, vig.Programs[lang.Ext])
+				continue
+			}
+			for _, file := range vig.Modules[lang.Ext] {
+				fmt.Fprintf(w, codeLevel+" %s (%s)\n```%s\n%s\n```\n", lang.Name, file.Path, fileFence(file), file.Content)
+			}
+		}
+	}
+	writeCode(false)
+	if len(vig.Meta.Args) > 0 {
+		fmt.Fprintf(w, "**Arguments**: `%s`\n\n", strings.Join(vig.Meta.Args, " "))
+	}
+	if vig.Meta.Stdin != "" {
+		fmt.Fprintf(w, "**Input**:\n```plaintext\n%s\n```\n", strings.TrimSuffix(vig.Meta.Stdin, "\n"))
+	}
+	if vig.Meta.Nondeterministic {
+		fmt.Fprintf(w, "%s\n\n", nondeterministicNote)
+	}
+	writeOutput(w, res)
+	writeCode(true)
+}
+
+// nondeterministicNote precedes the output of programs whose output varies between runs.
+const nondeterministicNote = "*The output of this program varies between runs.*"
+
+// writeOutput writes the output of a program run to w. Standard error is
+// written in its own block, followed by how the program exited if it failed.
+func writeOutput(w io.Writer, res Result) {
+	for _, b := range outputBlocks(res) {
+		fmt.Fprintf(w, "**%s**:\n```plaintext\n%s\n```\n\n", b.Label, b.Text)
+	}
+	if label, status := exitStatus(res); label != "" {
+		fmt.Fprintf(w, "**%s**: %s\n\n", label, status)
+	}
+}
+
+// outputBlock is a labelled block of the output of a program run.
+type outputBlock struct {
+	Label string
+	Text  string
+}
+
+// outputBlocks returns the blocks the output of a program run is rendered as:
+// compile errors, or the standard output and standard error of the program.
+func outputBlocks(res Result) []outputBlock {
+	block := func(label, text string) outputBlock {
+		return outputBlock{Label: label, Text: strings.TrimSuffix(text, "\n")}
+	}
+	switch {
+	case errors.Is(res.Err, ErrBuild):
+		return []outputBlock{block("Compile error", res.Output)}
+	case res.Stderr == "":
+		return []outputBlock{block("Output", res.Stdout)}
+	case res.Stdout == "":
+		return []outputBlock{block("Standard error", res.Stderr)}
+	}
+	return []outputBlock{block("Output", res.Stdout), block("Standard error", res.Stderr)}
+}
+
+// exitStatus returns how a program run exited if it did not exit with code 0,
+// i.e: its exit code or the signal which killed it. label is empty otherwise.
+func exitStatus(res Result) (label, status string) {
+	switch {
+	case errors.Is(res.Err, ErrBuild):
+		return "", ""
+	case res.ExitCode > 0:
+		return "Exit code", strconv.Itoa(res.ExitCode)
+	case res.ExitCode < 0 && res.Err != nil:
+		return "Exit status", res.Err.Error()
+	}
+	return "", ""
+}
+
+// hasRequiredCode reports whether vig has a program for every non-optional
+// language included in the document.
+func (d Document) hasRequiredCode(vig Vignette) bool {
+	for _, lang := range Languages {
+		if !lang.Optional && lang.Docs&d.Doc != 0 && !vig.HasProgram(lang.Ext) {
+			return false
+		}
+	}
+	return true
+}
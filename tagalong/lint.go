@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/scanner"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Finding is a problem found by linting a vignette program.
+type Finding struct {
+	// Pos is the position of the problem relative to the vignettes directory,
+	// i.e: "002-hello/hello.go:5:2". It is the vignette directory if the
+	// problem can not be attributed to a line.
+	Pos string
+	Msg string
+}
+
+func (f Finding) String() string {
+	return f.Pos + ": " + f.Msg
+}
+
+// cmdLint implements the lint subcommand, which checks the formatting of Go
+// vignette programs, runs go vet on them and optionally runs them under the
+// race detector. Findings are printed and cause a non-zero exit status.
+func cmdLint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	race := flags.Bool("race", false, "also run programs with the race detector enabled")
+	var limits Limits
+	flags.DurationVar(&limits.Timeout, "timeout", time.Minute, "wall time limit of each go vet and program run, 0 means no limit")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tagalong lint [flags] [vignette names]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	vignettes, err := ParseDirExercises(".")
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		selected := vignettes[:0]
+		for _, v := range vignettes {
+			if contains(flags.Args(), v.Name) || contains(flags.Args(), v.Code()) {
+				selected = append(selected, v)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("no vignettes named %q", flags.Args())
+		}
+		vignettes = selected
+	}
+	tmpdir, err := os.MkdirTemp("", "decaf-lint")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+	findings, skipped, err := LintVignettes(tmpdir, vignettes, limits, *race)
+	for _, h := range skipped {
+		log.Printf("%s: skipped, only Go programs of vignettes with a README are linted", h.Code())
+	}
+	if err != nil {
+		return err
+	}
+	for _, f := range findings {
+		fmt.Println(f)
+	}
+	if len(findings) > 0 {
+		return fmt.Errorf("%d lint findings", len(findings))
+	}
+	return nil
+}
+
+// LintVignettes lints the Go program of every vignette with a README in dir,
+// see [Vignette.LintGo]. Programs without a README, i.e: generative art, may
+// depend on packages and files of the repository so they can not be checked
+// in isolation. Their headers are returned in skipped.
+func LintVignettes(dir string, vignettes []Vignette, limits Limits, race bool) (findings []Finding, skipped []Header, err error) {
+	found := make([][]Finding, len(vignettes))
+	errs := make([]error, len(vignettes))
+	parallel(len(vignettes), func(i int) {
+		if runsGo(vignettes[i]) {
+			found[i], errs[i] = vignettes[i].LintGo(dir, limits, race)
+		}
+	})
+	for i, v := range vignettes {
+		if errs[i] != nil {
+			return nil, skipped, fmt.Errorf("linting %s: %w", v.Code(), errs[i])
+		}
+		if v.HasProgram(LangGo.Ext) && !runsGo(v) {
+			skipped = append(skipped, v.Header)
+		}
+		findings = append(findings, found[i]...)
+	}
+	return findings, skipped, nil
+}
+
+// LintGo checks that the vignette's Go program files are gofmt formatted and
+// that go vet reports no problems. If race is set the program is also run in
+// dir with the race detector enabled under limits and data races are reported.
+func (v *Vignette) LintGo(dir string, limits Limits, race bool) ([]Finding, error) {
+	var findings []Finding
+	files := v.ProgramFiles(LangGo.Ext)
+	for _, file := range files {
+		if !strings.HasSuffix(file.Path, ".go") {
+			continue
+		}
+		findings = append(findings, v.gofmt(file)...)
+	}
+	vetted, err := v.vet(dir, limits)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, vetted...)
+	if !race {
+		return findings, nil
+	}
+	// Build the program like the generator does so that it exits the same way.
+	root, err := os.MkdirTemp(dir, v.Name+"-race-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(root)
+	lang := LangGo
+	lang.Build = append([]string{"go", "build", "-race"}, LangGo.Build[2:]...)
+	res := NewExecutor(root, filepath.Join(root, "build"), limits).Execute(v, lang)
+	switch {
+	case strings.Contains(res.Output, "WARNING: DATA RACE"):
+		findings = append(findings, Finding{Pos: v.Code(), Msg: "data race detected:\n" + res.Output})
+	case res.Err != nil:
+		findings = append(findings, Finding{Pos: v.Code(), Msg: fmt.Sprintf("running with -race: %v\n%s", res.Err, res.Output)})
+	}
+	return findings, nil
+}
+
+// sourcePath returns the path of the vignette's program file relative to the
+// vignettes directory.
+func (v *Vignette) sourcePath(ext string, file File) string {
+	if len(v.Modules[ext]) > 0 {
+		return v.Code() + "/" + ext + "/" + file.Path
+	}
+	return v.Code() + "/" + file.Path
+}
+
+// gofmt reports the first line at which file differs from its gofmt formatting
+// or the syntax errors preventing it from being formatted.
+func (v *Vignette) gofmt(file File) []Finding {
+	path := v.sourcePath(LangGo.Ext, file)
+	formatted, err := format.Source([]byte(file.Content))
+	var list scanner.ErrorList
+	if errors.As(err, &list) {
+		var findings []Finding
+		for _, e := range list {
+			findings = append(findings, Finding{Pos: fmt.Sprintf("%s:%d:%d", path, e.Pos.Line, e.Pos.Column), Msg: e.Msg})
+		}
+		return findings
+	} else if err != nil {
+		return []Finding{{Pos: path, Msg: err.Error()}}
+	}
+	if bytes.Equal(formatted, []byte(file.Content)) {
+		return nil
+	}
+	have, want := splitLines(file.Content), splitLines(string(formatted))
+	line := 0
+	for line < len(have) && line < len(want) && have[line] == want[line] {
+		line++
+	}
+	return []Finding{{Pos: fmt.Sprintf("%s:%d", path, line+1), Msg: "not gofmt formatted"}}
+}
+
+// vetPos matches the position prefix of go vet and compiler messages.
+var vetPos = regexp.MustCompile(`^(?:vet: )?(\S+\.go):(\d+(?::\d+)?): (.*)$`)
+
+// vet runs go vet on the vignette's Go program copied to a new directory in dir.
+func (v *Vignette) vet(dir string, limits Limits) ([]Finding, error) {
+	root, err := os.MkdirTemp(dir, v.Name+"-vet-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(root)
+	files := v.ProgramFiles(LangGo.Ext)
+	err = writeModule(root, files)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"vet", "./..."}
+	if len(v.Modules[LangGo.Ext]) == 0 {
+		args = []string{"vet", files[0].Path}
+	}
+	cmd, err := limits.command("go", args)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Dir = root
+	var output lockedBuffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = limits.run(cmd, &output)
+	if exceededLimit(err) {
+		return nil, err
+	}
+	var findings []Finding
+	for _, line := range splitLines(output.String()) {
+		m := vetPos.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		filename := m[1]
+		if rel, err := filepath.Rel(root, filename); err == nil && filepath.IsAbs(filename) {
+			filename = rel
+		}
+		file := File{Path: filepath.ToSlash(filepath.Clean(filename))}
+		findings = append(findings, Finding{Pos: v.sourcePath(LangGo.Ext, file) + ":" + m[2], Msg: m[3]})
+	}
+	if err != nil && len(findings) == 0 {
+		findings = append(findings, Finding{Pos: v.Code(), Msg: fmt.Sprintf("go vet: %v\n%s", err, output.String())})
+	}
+	return findings, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLintVignettes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go vet")
+	}
+	const clean = "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n"
+	const racy = `package main
+
+import "fmt"
+
+func main() {
+	n := 0
+	done := make(chan bool)
+	go func() {
+		n++
+		done <- true
+	}()
+	n++
+	<-done
+	fmt.Println(n)
+}
+`
+	for _, test := range []struct {
+		name    string
+		program string
+		noMD    bool
+		race    bool
+		want    []string // Prefixes of the findings, "pos: msg".
+		skipped bool
+	}{
+		{name: "clean", program: clean, race: true},
+		{name: "gofmt", program: "package main\n\nfunc main()  {}\n", want: []string{"001-lint/lint.go:3: not gofmt formatted"}},
+		// Syntax errors are reported by both gofmt and go vet.
+		{name: "syntax", program: "package main\n\nfunc main() {\n", want: []string{"001-lint/lint.go:3:15: expected '}'", "001-lint/lint.go:3:15: expected '}'"}},
+		{name: "vet", program: strings.Replace(clean, `Println("hi")`, `Printf("%d\n", "hi")`, 1), want: []string{"001-lint/lint.go:6:"}},
+		{name: "race", program: racy, race: true, want: []string{"001-lint: data race detected:"}},
+		{name: "no README", program: "package main\n\nfunc main()  {}\n", noMD: true, skipped: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if test.race {
+				if _, err := exec.LookPath("gcc"); err != nil {
+					t.Skip("the race detector requires cgo")
+				}
+			}
+			v := Vignette{Header: Header{Num: 1, Name: "lint"}, MD: "# Lint\n", Programs: map[string]string{"go": test.program}}
+			if test.noMD {
+				v.MD = ""
+			}
+			findings, skipped, err := LintVignettes(t.TempDir(), []Vignette{v}, Limits{Timeout: time.Minute}, test.race)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(findings) != len(test.want) {
+				t.Fatalf("got findings %q, want %q", findings, test.want)
+			}
+			for i, f := range findings {
+				if !strings.HasPrefix(f.String(), test.want[i]) {
+					t.Errorf("got finding %q, want prefix %q", f, test.want[i])
+				}
+			}
+			var wantSkipped []Header
+			if test.skipped {
+				wantSkipped = []Header{v.Header}
+			}
+			if !reflect.DeepEqual(skipped, wantSkipped) {
+				t.Errorf("got skipped %v, want %v", skipped, wantSkipped)
+			}
+		})
+	}
+}
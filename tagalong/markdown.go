@@ -0,0 +1,100 @@
+package main
+
+import (
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	mdHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdOrdered  = regexp.MustCompile(`^\d+\.\s+(.*)$`)
+	mdCodeSpan = regexp.MustCompile("`([^`]+)`")
+	mdLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBold     = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdItalic   = regexp.MustCompile(`\*([^*\s](?:[^*]*[^*\s])?)\*`)
+	mdSpanRef  = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// MarkdownHTML converts the subset of markdown used in vignette READMEs to HTML:
+// headings, paragraphs, fenced code blocks, lists, links, emphasis and code spans.
+// Lines starting with an HTML tag are copied verbatim up to the next blank line.
+func MarkdownHTML(md string) template.HTML {
+	var b strings.Builder
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	var paragraph []string
+	list := "" // Tag of the open list, if any.
+	flush := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>" + inlineHTML(strings.Join(paragraph, "\n")) + "</p>\n")
+			paragraph = nil
+		}
+		if list != "" {
+			b.WriteString("</" + list + ">\n")
+			list = ""
+		}
+	}
+	listItem := func(tag, item string) {
+		if list != tag {
+			flush()
+			b.WriteString("<" + tag + ">\n")
+			list = tag
+		}
+		b.WriteString("<li>" + inlineHTML(item) + "</li>\n")
+	}
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "```"):
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+		case strings.HasPrefix(line, "<"):
+			flush()
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				b.WriteString(lines[i] + "\n")
+			}
+		case mdHeading.MatchString(line):
+			flush()
+			m := mdHeading.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + inlineHTML(m[2]) + "</h" + level + ">\n")
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			listItem("ul", line[2:])
+		case mdOrdered.MatchString(line):
+			listItem("ol", mdOrdered.FindStringSubmatch(line)[1])
+		default:
+			if list != "" {
+				flush()
+			}
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return template.HTML(b.String())
+}
+
+// inlineHTML escapes s and converts inline markdown markup to HTML.
+func inlineHTML(s string) string {
+	s = html.EscapeString(s)
+	// Code spans are set aside so that their contents are not converted.
+	var spans []string
+	s = mdCodeSpan.ReplaceAllStringFunc(s, func(span string) string {
+		spans = append(spans, "<code>"+span[1:len(span)-1]+"</code>")
+		return "\x00" + strconv.Itoa(len(spans)-1) + "\x00"
+	})
+	s = mdLink.ReplaceAllString(s, `<a href="$2">$1</a>`)
+	s = mdBold.ReplaceAllString(s, "<strong>$1</strong>")
+	s = mdItalic.ReplaceAllString(s, "<em>$1</em>")
+	return mdSpanRef.ReplaceAllStringFunc(s, func(ref string) string {
+		n, _ := strconv.Atoi(ref[1 : len(ref)-1])
+		return spans[n]
+	})
+}
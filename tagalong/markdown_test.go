@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestMarkdownHTML(t *testing.T) {
+	for _, test := range []struct {
+		md   string
+		want string
+	}{
+		{md: "# Title\nSome `a<b` **bold** and *emphasis*.", want: "<h1>Title</h1>\n<p>Some <code>a&lt;b</code> <strong>bold</strong> and <em>emphasis</em>.</p>\n"},
+		{md: "See [`io.Reader`](https://pkg.go.dev/io#Reader).", want: "<p>See <a href=\"https://pkg.go.dev/io#Reader\"><code>io.Reader</code></a>.</p>\n"},
+		{md: "- a\n- b\n\n1. c", want: "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n<ol>\n<li>c</li>\n</ol>\n"},
+		{md: "```go\n*p = 2\n```", want: "<pre><code>*p = 2</code></pre>\n"},
+		{md: "<details><pre>\n1 2\n</pre></details>\n\ntext", want: "<details><pre>\n1 2\n</pre></details>\n<p>text</p>\n"},
+	} {
+		got := string(MarkdownHTML(test.md))
+		if got != test.want {
+			t.Errorf("MarkdownHTML(%q):\ngot  %q\nwant %q", test.md, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MetaFilename is the name of the optional vignette metadata sidecar file.
+// It holds the same "key: value" lines as README front matter.
+const MetaFilename = "meta.txt"
+
+// Meta is optional vignette metadata declared in the README front matter,
+// delimited by "---" lines at the start of the README, or in a sidecar file.
+//
+//	---
+//	title: Hello World
+//	tags: [basics, fmt]
+//	difficulty: beginner
+//	prerequisites: [hello]
+//	args: [-n, 3]
+//	stdin: "Gopher\n"
+//	env: [GREETING=hi]
+//	nondeterministic: true
+//	exit: 2
+//	---
+type Meta struct {
+	Title      string
+	Tags       []string
+	Difficulty string
+	// Prerequisites holds names of vignettes to be read before this one.
+	Prerequisites []string
+	// Args, Stdin and Env are passed to the vignette's programs when run.
+	Args  []string
+	Stdin string
+	Env   []string
+	// Nondeterministic is set if the program output is intended to vary between runs.
+	Nondeterministic bool
+	// Exit is the exit code expected of the Go program, i.e: 2 for a program
+	// which panics on purpose. Other exit codes are failures.
+	Exit int
+}
+
+// summary returns a line describing the difficulty, tags and
+// prerequisites of the vignette or the empty string if there are none.
+func (m Meta) summary() string {
+	var parts []string
+	if m.Difficulty != "" {
+		parts = append(parts, "Difficulty: "+m.Difficulty)
+	}
+	if len(m.Tags) > 0 {
+		parts = append(parts, "Tags: "+strings.Join(m.Tags, ", "))
+	}
+	if len(m.Prerequisites) > 0 {
+		parts = append(parts, "Prerequisites: "+strings.Join(m.Prerequisites, ", "))
+	}
+	return strings.Join(parts, ". ")
+}
+
+// splitFrontMatter separates the front matter of a README from its contents.
+func splitFrontMatter(md string) (frontMatter, rest string) {
+	const delim = "---\n"
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	if !strings.HasPrefix(md, delim) {
+		return "", md
+	}
+	end := strings.Index(md[len(delim):], "\n"+delim)
+	if end < 0 {
+		return "", md
+	}
+	end += len(delim)
+	return md[len(delim) : end+1], md[end+1+len(delim):]
+}
+
+// ParseMeta parses metadata "key: value" lines. List values are enclosed in
+// brackets and separated by commas. Values may be double quoted Go strings.
+// Blank lines and lines starting with '#' are ignored.
+func ParseMeta(text string) (meta Meta, err error) {
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return meta, fmt.Errorf("line %d: expected \"key: value\", got %q", n+1, line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		var dst *[]string
+		switch key {
+		case "title":
+			meta.Title, err = metaString(value)
+		case "difficulty":
+			meta.Difficulty, err = metaString(value)
+		case "stdin":
+			meta.Stdin, err = metaString(value)
+		case "nondeterministic":
+			meta.Nondeterministic, err = strconv.ParseBool(value)
+		case "exit":
+			meta.Exit, err = strconv.Atoi(value)
+		case "tags":
+			dst = &meta.Tags
+		case "prerequisites":
+			dst = &meta.Prerequisites
+		case "args":
+			dst = &meta.Args
+		case "env":
+			dst = &meta.Env
+		default:
+			return meta, fmt.Errorf("line %d: unknown key %q", n+1, key)
+		}
+		if dst != nil {
+			*dst, err = metaList(value)
+		}
+		if err != nil {
+			return meta, fmt.Errorf("line %d: %s: %w", n+1, key, err)
+		}
+	}
+	return meta, nil
+}
+
+func metaString(value string) (string, error) {
+	if strings.HasPrefix(value, `"`) {
+		return strconv.Unquote(value)
+	}
+	return value, nil
+}
+
+func metaList(value string) ([]string, error) {
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil, errors.New("expected list in brackets, i.e: [a, b]")
+	}
+	value = strings.TrimSpace(value[1 : len(value)-1])
+	if value == "" {
+		return nil, nil
+	}
+	var list []string
+	for _, elem := range splitList(value) {
+		s, err := metaString(strings.TrimSpace(elem))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// splitList splits the comma separated items of a list, ignoring commas in
+// double quoted items.
+func splitList(value string) []string {
+	var items []string
+	start := 0
+	quoted, escaped := false, false
+	for i, c := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			items = append(items, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, value[start:])
+}
+
+// validatePrerequisites checks that the prerequisites of every vignette
+// exist and precede it. vignettes must be sorted by number.
+func validatePrerequisites(vignettes []Vignette) error {
+	nums := make(map[string]int, len(vignettes))
+	for _, v := range vignettes {
+		nums[v.Name] = v.Num
+	}
+	for _, v := range vignettes {
+		for _, prereq := range v.Meta.Prerequisites {
+			num, ok := nums[prereq]
+			if !ok {
+				return fmt.Errorf("%s: prerequisite %q not found", v.Code(), prereq)
+			}
+			if num >= v.Num {
+				return fmt.Errorf("%s: prerequisite %q must have a lower number", v.Code(), Header{Num: num, Name: prereq}.Code())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	const readme = "---\ntitle: \"Hello: World\"\ntags: [basics, fmt]\n# Comment.\nargs: []\nstdin: \"a\\nb\"\nexit: 2\n---\n# Hello\n"
+	frontMatter, md := splitFrontMatter(readme)
+	if md != "# Hello\n" {
+		t.Errorf("got README %q", md)
+	}
+	meta, err := ParseMeta(frontMatter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Meta{Title: "Hello: World", Tags: []string{"basics", "fmt"}, Stdin: "a\nb", Exit: 2}
+	if !reflect.DeepEqual(meta, want) {
+		t.Errorf("got %+v, want %+v", meta, want)
+	}
+	_, err = ParseMeta("level: 3")
+	if err == nil {
+		t.Error("expected error for unknown key")
+	}
+	_, err = ParseMeta("exit: panic")
+	if err == nil {
+		t.Error("expected error for non-numeric exit code")
+	}
+}
+
+func TestMetaList(t *testing.T) {
+	for _, test := range []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{value: "[]", want: nil},
+		{value: "[a, b]", want: []string{"a", "b"}},
+		{value: `["a,b", c]`, want: []string{"a,b", "c"}},
+		{value: `[-sep, ", ", "say \"hi, there\""]`, want: []string{"-sep", ", ", `say "hi, there"`}},
+		{value: `["a\\", b]`, want: []string{`a\`, "b"}},
+		{value: `["a, b]`, wantErr: true},
+		{value: "a, b", wantErr: true},
+	} {
+		got, err := metaList(test.value)
+		if (err != nil) != test.wantErr {
+			t.Errorf("metaList(%s): got error %v, want error %v", test.value, err, test.wantErr)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("metaList(%s) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestMetaDeclaredTwice(t *testing.T) {
+	dir := t.TempDir()
+	vig := filepath.Join(dir, "002-hello")
+	err := os.Mkdir(vig, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"README.md":  "---\ntitle: Hello\n---\n# Hello\n",
+		MetaFilename: "title: Hi\n",
+	} {
+		err = os.WriteFile(filepath.Join(vig, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = ParseDirExercises(dir)
+	if err == nil || !strings.Contains(err.Error(), "metadata is declared in both") {
+		t.Errorf("ParseDirExercises: got error %v, want metadata declared twice", err)
+	}
+}
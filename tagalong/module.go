@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// File is a source file of a vignette program.
+type File struct {
+	// Path is the slash separated path of the file relative to the program root.
+	Path    string
+	Content string
+}
+
+// HasProgram reports whether the vignette has a single or multi-file program
+// written in the language with file extension ext.
+func (v *Vignette) HasProgram(ext string) bool {
+	return v.Programs[ext] != "" || len(v.Modules[ext]) > 0
+}
+
+// ProgramFiles returns the files of the vignette's program written in the
+// language with file extension ext. A single-file program is named after the vignette.
+func (v *Vignette) ProgramFiles(ext string) []File {
+	if files := v.Modules[ext]; len(files) > 0 {
+		return files
+	}
+	if v.Programs[ext] == "" {
+		return nil
+	}
+	return []File{{Path: v.Name + "." + ext, Content: v.Programs[ext]}}
+}
+
+// readModule reads the files of the module tree rooted at root.
+// Hidden files and directories are ignored.
+func readModule(root string) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(root, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filename != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filename)
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Path: filepath.ToSlash(rel), Content: string(b)})
+		return nil
+	})
+	return files, err
+}
+
+// writeModule writes files to the directory root.
+func writeModule(root string, files []File) error {
+	for _, file := range files {
+		filename := filepath.Join(root, filepath.FromSlash(file.Path))
+		err := os.MkdirAll(filepath.Dir(filename), 0o755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(filename, []byte(file.Content), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileFence returns the markdown code block info string for the file.
+func fileFence(file File) string {
+	lang, ok := LookupLanguage(strings.TrimPrefix(path.Ext(file.Path), "."))
+	if !ok {
+		return "plaintext"
+	}
+	return lang.Fence
+}
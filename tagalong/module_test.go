@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadWriteModule(t *testing.T) {
+	files := []File{
+		{Path: "go.mod", Content: "module example.com/m\n\ngo 1.19\n"},
+		{Path: "greet/greet.go", Content: "package greet\n\nconst Hello = \"hi\"\n"},
+		{Path: "main.go", Content: "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/m/greet\"\n)\n\nfunc main() { fmt.Println(greet.Hello) }\n"},
+	}
+	root := t.TempDir()
+	err := writeModule(root, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Hidden files and directories are not part of the module.
+	err = os.WriteFile(filepath.Join(root, ".hidden"), []byte("x"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Join(root, ".git"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readModule(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, files) {
+		t.Errorf("readModule after writeModule:\ngot  %q\nwant %q", got, files)
+	}
+}
+
+func TestExecuteModule(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go")
+	}
+	v := &Vignette{Header: Header{Num: 3, Name: "packages"}, Modules: map[string][]File{"go": {
+		{Path: "go.mod", Content: "module example.com/m\n\ngo 1.19\n"},
+		{Path: "greet/greet.go", Content: "package greet\n\nconst Hello = \"hi\"\n"},
+		{Path: "main.go", Content: "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/m/greet\"\n)\n\nfunc main() { fmt.Println(greet.Hello) }\n"},
+	}}}
+	dir := t.TempDir()
+	limits := Limits{Timeout: time.Minute}
+	for name, executor := range map[string]*Executor{
+		"run":   {Dir: dir, Limits: limits},
+		"build": {Dir: dir, Limits: limits, Builds: &BuildCache{Dir: filepath.Join(dir, "build")}},
+	} {
+		res := executor.Execute(v, LangGo)
+		if res.Err != nil || res.Stdout != "hi\n" {
+			t.Errorf("%s: got %q, %v, want \"hi\\n\"", name, res.Output, res.Err)
+		}
+	}
+}
+
+func TestParseDirRejectsProgramAndModule(t *testing.T) {
+	dir := t.TempDir()
+	vig := filepath.Join(dir, "002-hello")
+	err := os.MkdirAll(filepath.Join(vig, "go"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"README.md":  "# Hello\n",
+		"hello.go":   "package main\n",
+		"go/main.go": "package main\n",
+	} {
+		err = os.WriteFile(filepath.Join(vig, filepath.FromSlash(name)), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = ParseDirExercises(dir)
+	if err == nil || !strings.Contains(err.Error(), "both hello.go and go/") {
+		t.Errorf("ParseDirExercises: got error %v, want both programs reported", err)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Jupyter notebook document in nbformat v4.5, see https://nbformat.readthedocs.io.
+type notebook struct {
+	Cells         []notebookCell   `json:"cells"`
+	Metadata      notebookMetadata `json:"metadata"`
+	NBFormat      int              `json:"nbformat"`
+	NBFormatMinor int              `json:"nbformat_minor"`
+}
+
+type notebookMetadata struct {
+	KernelSpec   notebookKernelSpec       `json:"kernelspec"`
+	LanguageInfo notebookLanguageMetadata `json:"language_info"`
+}
+
+type notebookKernelSpec struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Language    string `json:"language"`
+}
+
+type notebookLanguageMetadata struct {
+	Name string `json:"name"`
+}
+
+type notebookCell struct {
+	CellType string            `json:"cell_type"`
+	ID       string            `json:"id"`
+	Metadata map[string]string `json:"metadata"`
+	Source   []string          `json:"source"`
+	// ExecutionCount and Outputs are only present in code cells.
+	ExecutionCount json.RawMessage   `json:"execution_count,omitempty"`
+	Outputs        *[]notebookOutput `json:"outputs,omitempty"`
+}
+
+type notebookOutput struct {
+	OutputType string   `json:"output_type"`
+	Name       string   `json:"name"`
+	Text       []string `json:"text"`
+}
+
+var notebookIDInvalid = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// WriteNotebook writes the vignettes as a Jupyter notebook to filename. Each
+// vignette's README is written as a markdown cell, opened by an anchor which
+// links between vignettes point to, followed by a code cell per
+// program file of every non-optional language. results maps a language's file
+// extension to its program results, which are embedded as the outputs of the
+// language's last cell so that the notebook renders without a kernel.
+func WriteNotebook(filename string, vignettes []Vignette, results map[string][]Result) error {
+	nb := notebook{
+		Metadata: notebookMetadata{
+			KernelSpec:   notebookKernelSpec{Name: "python3", DisplayName: "Python 3", Language: "python"},
+			LanguageInfo: notebookLanguageMetadata{Name: "python"},
+		},
+		NBFormat:      4,
+		NBFormatMinor: 5,
+	}
+	// Links between vignettes point to anchors opening their markdown cells.
+	anchors := make(map[string]string)
+	for _, vig := range vignettes {
+		if vig.MD != "" {
+			anchors[vig.Code()] = "#" + vig.Code()
+		}
+	}
+	for i, vig := range vignettes {
+		if vig.MD == "" {
+			continue
+		}
+		id := notebookIDInvalid.ReplaceAllString(vig.Code(), "_")
+		md := `<a id="` + vig.Code() + `"></a>` + "\n\n" + string(rewriteVignetteLinks([]byte(vig.MD), anchors))
+		nb.Cells = append(nb.Cells, notebookCell{
+			CellType: "markdown",
+			ID:       id + "-md",
+			Metadata: map[string]string{},
+			Source:   notebookLines(md),
+		})
+		for _, lang := range Languages {
+			if lang.Optional || !vig.HasProgram(lang.Ext) {
+				continue
+			}
+			files := vig.ProgramFiles(lang.Ext)
+			for j, file := range files {
+				source := file.Content
+				if len(files) > 1 {
+					source = "# " + file.Path + "\n" + source
+				}
+				outputs := []notebookOutput{}
+				if j == len(files)-1 && results[lang.Ext] != nil {
+					res := results[lang.Ext][i]
+					outputs = appendStream(outputs, "stdout", res.Stdout)
+					outputs = appendStream(outputs, "stderr", res.Stderr)
+				}
+				nb.Cells = append(nb.Cells, notebookCell{
+					CellType: "code",
+					ID:       id + "-" + lang.Ext + "-" + strconv.Itoa(j),
+					// Editors such as VS Code highlight cells according to their language.
+					Metadata:       map[string]string{"language": lang.Fence},
+					Source:         notebookLines(strings.TrimSuffix(source, "\n")),
+					ExecutionCount: json.RawMessage("null"),
+					Outputs:        &outputs,
+				})
+			}
+		}
+	}
+	b, err := json.MarshalIndent(nb, "", " ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(b, '\n'), 0o644)
+}
+
+func appendStream(outputs []notebookOutput, name, text string) []notebookOutput {
+	if text == "" {
+		return outputs
+	}
+	return append(outputs, notebookOutput{OutputType: "stream", Name: name, Text: notebookLines(text)})
+}
+
+// notebookLines splits s into lines keeping the line endings, as is
+// conventional for multiline strings in notebooks.
+func notebookLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines // Never nil since s="" yields an empty slice.
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestWriteNotebook checks the notebook against the structure required by the nbformat v4 schema.
+func TestWriteNotebook(t *testing.T) {
+	vignettes := []Vignette{
+		{Header: Header{Num: 1, Name: "intro"}, MD: "# Intro\nSee [hello](#002-hello) and [art](#900-art).\n"},
+		{Header: Header{Num: 2, Name: "hello"}, MD: "# Hello\nHi.\n", Programs: map[string]string{
+			"go": "package main\n", "py": "print('hi')\n", "zig": "const std = @import(\"std\");\n",
+		}},
+	}
+	results := map[string][]Result{
+		"go": {{}, {Stdout: "hi\n", Stderr: "oops\n"}},
+	}
+	filename := filepath.Join(t.TempDir(), "tagalong.ipynb")
+	err := WriteNotebook(filename, vignettes, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nb map[string]any
+	err = json.Unmarshal(b, &nb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nb["nbformat"] != 4.0 || nb["nbformat_minor"] != 5.0 {
+		t.Errorf("want nbformat 4.5, got %v.%v", nb["nbformat"], nb["nbformat_minor"])
+	}
+	if _, ok := nb["metadata"].(map[string]any); !ok {
+		t.Error("missing metadata object")
+	}
+	cells, _ := nb["cells"].([]any)
+	wantTypes := []string{"markdown", "markdown", "code", "code"} // Zig is optional and left out.
+	if len(cells) != len(wantTypes) {
+		t.Fatalf("want %d cells, got %d", len(wantTypes), len(cells))
+	}
+	validID := regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+	ids := make(map[string]bool)
+	for i, c := range cells {
+		cell := c.(map[string]any)
+		id, _ := cell["id"].(string)
+		if !validID.MatchString(id) || ids[id] {
+			t.Errorf("cell %d: invalid or duplicate id %q", i, id)
+		}
+		ids[id] = true
+		if cell["cell_type"] != wantTypes[i] {
+			t.Errorf("cell %d: want type %s, got %v", i, wantTypes[i], cell["cell_type"])
+		}
+		if _, ok := cell["metadata"].(map[string]any); !ok {
+			t.Errorf("cell %d: missing metadata object", i)
+		}
+		if _, ok := cell["source"].([]any); !ok {
+			t.Errorf("cell %d: source is not a list of strings", i)
+		}
+		count, hasCount := cell["execution_count"]
+		_, hasOutputs := cell["outputs"].([]any)
+		if isCode := cell["cell_type"] == "code"; isCode != (hasCount && count == nil) || isCode != hasOutputs {
+			t.Errorf("cell %d: execution_count and outputs must be present only in code cells", i)
+		}
+	}
+	var intro strings.Builder
+	for _, line := range cells[0].(map[string]any)["source"].([]any) {
+		intro.WriteString(line.(string))
+	}
+	wantIntro := "<a id=\"001-intro\"></a>\n\n# Intro\nSee [hello](#002-hello) and art.\n"
+	if intro.String() != wantIntro {
+		t.Errorf("want markdown cell %q, got %q", wantIntro, intro.String())
+	}
+	outputs := cells[3].(map[string]any)["outputs"].([]any)
+	if len(outputs) != 2 {
+		t.Fatalf("want stdout and stderr outputs, got %v", outputs)
+	}
+	for i, name := range []string{"stdout", "stderr"} {
+		out := outputs[i].(map[string]any)
+		if out["output_type"] != "stream" || out["name"] != name {
+			t.Errorf("output %d: want %s stream, got %v", i, name, out)
+		}
+	}
+}
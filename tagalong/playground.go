@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+var playgroundIndex = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Tagalong playground</title>
+<link rel="stylesheet" href="tagalong.css">
+</head>
+<body>
+<main>
+<h1>Tagalong playground</h1>
+<ol class="index">
+{{- range .}}
+<li value="{{.Num}}"><a href="{{.Code}}">{{.Name}}</a></li>
+{{- end}}
+</ol>
+</main>
+</body>
+</html>
+`))
+
+var playgroundPage = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Code}} - Tagalong playground</title>
+<link rel="stylesheet" href="tagalong.css">
+</head>
+<body>
+<nav class="pager"><a href=".">Index</a></nav>
+<main>
+<h1>{{.Code}}</h1>
+<textarea id="code" spellcheck="false" rows="24" style="width: 100%; font-family: monospace">{{.Program}}</textarea>
+<p><button id="run">Run</button> <span id="status"></span></p>
+<section class="output">
+<h3>Output</h3>
+<pre id="stdout"></pre>
+<h3>Standard error</h3>
+<pre id="stderr"></pre>
+</section>
+</main>
+<script>
+document.getElementById("run").onclick = async function() {
+	const status = document.getElementById("status");
+	status.textContent = "Running...";
+	const resp = await fetch("run", {
+		method: "POST",
+		headers: {"Content-Type": "application/json", "X-Playground-Token": {{.Token}}},
+		body: JSON.stringify({vignette: {{.Code}}, code: document.getElementById("code").value}),
+	});
+	const res = await resp.json();
+	document.getElementById("stdout").textContent = res.stdout || "";
+	document.getElementById("stderr").textContent = res.stderr || "";
+	status.textContent = res.error ? res.error : "Exit code " + res.exitCode;
+};
+</script>
+</body>
+</html>
+`))
+
+// playgroundRequest is the body of a request to run edited vignette code.
+type playgroundRequest struct {
+	Vignette string `json:"vignette"`
+	Code     string `json:"code"`
+}
+
+// playgroundResponse is the result of running edited vignette code.
+type playgroundResponse struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exitCode"`
+	Error    string `json:"error,omitempty"`
+}
+
+// playground serves pages to edit and run the Go programs of vignettes.
+type playground struct {
+	vignettes map[string]Vignette // Vignettes with single-file Go programs by code.
+	limits    Limits
+	maxBytes  int64
+	// running bounds the number of programs run at once.
+	running chan struct{}
+	// token is embedded in served pages and required by run requests, so
+	// that other web pages can not make the browser run programs.
+	token string
+	// hosts are the Host header values of requests to the playground, which
+	// rejects others so that DNS rebinding pages can not reach it.
+	hosts map[string]bool
+}
+
+// cmdPlayground implements the playground subcommand, which serves an HTTP
+// playground to edit each vignette's Go program and run it. It is meant for
+// local use and listens on the loopback interface by default.
+func cmdPlayground(args []string) error {
+	flags := flag.NewFlagSet("playground", flag.ExitOnError)
+	addr := flags.String("http", "localhost:8001", "address to serve the playground on, beware other addresses let anyone reaching it run programs on this machine")
+	maxBytes := flags.Int64("maxbytes", 64<<10, "maximum size in bytes of a run request")
+	var limits Limits
+	flags.DurationVar(&limits.Timeout, "timeout", 10*time.Second, "wall time limit of each program run")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tagalong playground [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if limits.Timeout <= 0 {
+		return errors.New("the playground requires a positive -timeout")
+	}
+	hosts, err := playgroundHosts(*addr)
+	if err != nil {
+		return err
+	}
+	token := make([]byte, 16)
+	_, err = rand.Read(token)
+	if err != nil {
+		return err
+	}
+	vignettes, err := ParseDirExercises(".")
+	if err != nil {
+		return err
+	}
+	p := &playground{
+		vignettes: make(map[string]Vignette),
+		limits:    limits,
+		maxBytes:  *maxBytes,
+		running:   make(chan struct{}, runtime.GOMAXPROCS(0)),
+		token:     hex.EncodeToString(token),
+		hosts:     hosts,
+	}
+	var index []Vignette
+	for _, v := range vignettes {
+		if runsGo(v) && v.Programs[LangGo.Ext] != "" {
+			p.vignettes[v.Code()] = v
+			index = append(index, v)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if !p.hosts[r.Host] {
+			http.Error(w, "forbidden host", http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/" {
+			writePage(w, playgroundIndex, index)
+			return
+		}
+		v, ok := p.vignettes[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		writePage(w, playgroundPage, struct{ Code, Program, Token string }{v.Code(), v.Programs[LangGo.Ext], p.token})
+	})
+	mux.HandleFunc("/run", p.serveRun)
+	mux.HandleFunc("/tagalong.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		w.Write([]byte(siteCSS))
+	})
+	// Shut down on interrupt so that running programs and their directories are cleaned up.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	server := &http.Server{Addr: *addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+	log.Printf("serving playground at http://%s", *addr)
+	err = server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// playgroundHosts returns the Host header values of requests to a playground
+// listening on addr. Loopback addresses may be reached by any loopback name.
+func playgroundHosts(addr string) (map[string]bool, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return map[string]bool{
+			"localhost:" + port: true,
+			"127.0.0.1:" + port: true,
+			"[::1]:" + port:     true,
+		}, nil
+	}
+	log.Printf("warning: %s is not a loopback address, anyone reaching it can run programs on this machine", addr)
+	return map[string]bool{addr: true}, nil
+}
+
+// checkRun returns an error if r is not a run request sent by a playground page.
+func (p *playground) checkRun(r *http.Request) error {
+	if !p.hosts[r.Host] {
+		return errors.New("forbidden host " + r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && origin != "http://"+r.Host {
+		return errors.New("forbidden origin " + origin)
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return errors.New("content type must be application/json")
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Playground-Token")), []byte(p.token)) != 1 {
+		return errors.New("missing or invalid playground token, reload the page")
+	}
+	return nil
+}
+
+// serveRun builds and runs the edited Go program of a vignette and replies
+// with its output and exit code as JSON. Compile errors are in stderr.
+func (p *playground) serveRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := p.checkRun(r); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	var req playgroundRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, p.maxBytes)).Decode(&req)
+	if err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	v, ok := p.vignettes[req.Vignette]
+	if !ok {
+		http.Error(w, "no vignette "+req.Vignette, http.StatusNotFound)
+		return
+	}
+	v.Programs = map[string]string{LangGo.Ext: req.Code}
+
+	select {
+	case p.running <- struct{}{}:
+		defer func() { <-p.running }()
+	case <-r.Context().Done():
+		return
+	}
+	// Each run gets its own directory, holding the executable built from the
+	// edited program, so that concurrent runs of the same vignette do not
+	// collide and nothing is left behind by the run.
+	dir, err := os.MkdirTemp("", "decaf-playground")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+	executor := NewExecutor(dir, filepath.Join(dir, "build"), p.limits)
+	res := executor.Execute(&v, LangGo)
+	resp := playgroundResponse{Stdout: res.Stdout, Stderr: res.Stderr, ExitCode: res.ExitCode}
+	if res.Err != nil {
+		resp.Error = res.Err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func writePage(w http.ResponseWriter, tmpl *template.Template, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := tmpl.Execute(w, data)
+	if err != nil {
+		log.Println(err)
+	}
+}
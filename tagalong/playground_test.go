@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPlaygroundCheckRun(t *testing.T) {
+	hosts, err := playgroundHosts("localhost:8001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &playground{token: "secret", hosts: hosts}
+	for _, test := range []struct {
+		name                       string
+		host, origin, ctype, token string
+		ok                         bool
+	}{
+		{name: "page", host: "localhost:8001", origin: "http://localhost:8001", ctype: "application/json", token: "secret", ok: true},
+		{name: "loopback IP", host: "127.0.0.1:8001", ctype: "application/json; charset=utf-8", token: "secret", ok: true},
+		{name: "simple request", host: "localhost:8001", origin: "http://evil.example", ctype: "text/plain", token: "secret"},
+		{name: "other origin", host: "localhost:8001", origin: "http://evil.example", ctype: "application/json", token: "secret"},
+		{name: "DNS rebinding", host: "evil.example:8001", origin: "http://evil.example:8001", ctype: "application/json", token: "secret"},
+		{name: "other port", host: "localhost:9000", ctype: "application/json", token: "secret"},
+		{name: "no token", host: "localhost:8001", ctype: "application/json"},
+		{name: "wrong token", host: "localhost:8001", ctype: "application/json", token: "guess"},
+	} {
+		r := httptest.NewRequest("POST", "/run", strings.NewReader("{}"))
+		r.Host = test.host
+		if test.origin != "" {
+			r.Header.Set("Origin", test.origin)
+		}
+		r.Header.Set("Content-Type", test.ctype)
+		if test.token != "" {
+			r.Header.Set("X-Playground-Token", test.token)
+		}
+		err := p.checkRun(r)
+		if (err == nil) != test.ok {
+			t.Errorf("%s: got error %v, want ok %v", test.name, err, test.ok)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// ExpandREADMEs executes each vignette README as a text/template and replaces
+// it with the result. results holds the Go program result of each vignette.
+// The following functions are available to READMEs:
+//
+//	{{region "go" "name"}}  lines between "// region name" and "// endregion"
+//	                        markers in the vignette's program in the given language.
+//	{{output}}              the vignette's Go program output.
+//	{{link "name"}}         a markdown link to the anchor of the vignette with the given name.
+//
+// It fails if a README references a region or vignette that does not exist.
+func ExpandREADMEs(vignettes []Vignette, results []Result) error {
+	byName := make(map[string]*Vignette, len(vignettes))
+	for i := range vignettes {
+		byName[vignettes[i].Name] = &vignettes[i]
+	}
+	// Links are resolved against the READMEs as written, so titles
+	// are found before any README is replaced.
+	titles := make(map[string]string, len(vignettes))
+	for _, v := range vignettes {
+		titles[v.Name] = vignetteTitle(v)
+	}
+	for i := range vignettes {
+		v := &vignettes[i]
+		if !strings.Contains(v.MD, "{{") {
+			continue
+		}
+		funcs := template.FuncMap{
+			"region": func(ext, name string) (string, error) {
+				return v.region(ext, name)
+			},
+			"output": func() string {
+				return strings.TrimSuffix(results[i].Output, "\n")
+			},
+			"link": func(name string) (string, error) {
+				target, ok := byName[name]
+				if !ok {
+					return "", fmt.Errorf("no vignette named %q", name)
+				}
+				return fmt.Sprintf("[%s](#%s)", titles[name], target.Code()), nil
+			},
+		}
+		tmpl, err := template.New(v.Code()).Funcs(funcs).Parse(v.MD)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		err = tmpl.Execute(&buf, nil)
+		if err != nil {
+			return err
+		}
+		v.MD = buf.String()
+	}
+	return nil
+}
+
+// region returns the lines between the "region name" and "endregion" marker
+// comments in the vignette's program written in the language with file extension
+// ext. Indentation common to all lines is removed.
+func (v *Vignette) region(ext, name string) (string, error) {
+	lang, ok := LookupLanguage(ext)
+	if !ok || lang.Comment == "" {
+		return "", fmt.Errorf("no language with file extension %q and line comments", ext)
+	}
+	start := lang.Comment + " region " + name
+	end := lang.Comment + " endregion"
+	for _, file := range v.ProgramFiles(ext) {
+		var lines []string
+		found := false
+		for _, line := range strings.Split(file.Content, "\n") {
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case !found && trimmed == start:
+				found = true
+			case found && trimmed == end:
+				return dedent(lines), nil
+			case found:
+				lines = append(lines, line)
+			}
+		}
+		if found {
+			return "", fmt.Errorf("%s: region %q has no end marker %q", file.Path, name, end)
+		}
+	}
+	return "", fmt.Errorf("no region %q in %s program of %s", name, lang.Name, v.Code())
+}
+
+// dedent joins lines removing the leading whitespace common to all non-blank lines.
+func dedent(lines []string) string {
+	prefix := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, prefix)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandREADMEs(t *testing.T) {
+	program := "package main\n\nfunc main() {\n\t// region greet\n\tif true {\n\t\tprintln(\"hi\")\n\t}\n\t// endregion\n}\n"
+	for _, test := range []struct {
+		md      string
+		want    string
+		wantErr string
+	}{
+		{md: "# Hello\nNo templating.", want: "# Hello\nNo templating."},
+		{md: "```go\n{{region \"go\" \"greet\"}}\n```", want: "```go\nif true {\n\tprintln(\"hi\")\n}\n```"},
+		{md: "Prints `{{output}}`, see {{link \"intro\"}}.", want: "Prints `hi`, see [Introduction](#001-intro)."},
+		{md: "{{region \"go\" \"missing\"}}", wantErr: `no region "missing"`},
+		{md: "{{region \"py\" \"greet\"}}", wantErr: `no region "greet"`},
+		{md: "{{link \"nope\"}}", wantErr: `no vignette named "nope"`},
+	} {
+		vignettes := []Vignette{
+			{Header: Header{Num: 1, Name: "intro"}, MD: "# Introduction\n"},
+			{Header: Header{Num: 2, Name: "hello"}, MD: test.md, Programs: map[string]string{"go": program}},
+		}
+		results := []Result{{}, {Output: "hi\n"}}
+		err := ExpandREADMEs(vignettes, results)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ExpandREADMEs(%q): want error containing %q, got %v", test.md, test.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ExpandREADMEs(%q): %v", test.md, err)
+		} else if got := vignettes[1].MD; got != test.want {
+			t.Errorf("ExpandREADMEs(%q):\ngot  %q\nwant %q", test.md, got, test.want)
+		}
+	}
+}
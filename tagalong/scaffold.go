@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cmdNew implements the new subcommand, which creates a vignette with README
+// and program templates after an existing vignette, shifting later vignettes.
+func cmdNew(args []string) error {
+	flags := flag.NewFlagSet("new", flag.ExitOnError)
+	after := flags.String("after", "", "number of the vignette after which the new vignette is inserted, defaults to the last vignette")
+	dryRun := flags.Bool("n", false, "print changes without making them")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tagalong new [flags] <name>")
+		flags.PrintDefaults()
+	}
+	// Flags may also follow the vignette name.
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return fmt.Errorf("%w: missing vignette name", errUsage)
+	}
+	name := flags.Arg(0)
+	flags.Parse(flags.Args()[1:])
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return fmt.Errorf("%w: unexpected arguments %q", errUsage, flags.Args())
+	}
+	if strings.ContainsAny(name, `/\. `) {
+		return fmt.Errorf("invalid vignette name %q", name)
+	}
+	afterNum := -1
+	if *after != "" {
+		n, err := strconv.Atoi(*after)
+		if err != nil {
+			return fmt.Errorf("invalid -after number: %w", err)
+		}
+		afterNum = n
+	}
+	return newVignette(".", name, afterNum, *dryRun)
+}
+
+// newVignette creates the vignette name with README and program templates in
+// dir after the vignette numbered after, or after the last vignette if after
+// is negative, shifting later vignettes.
+func newVignette(dir, name string, after int, dryRun bool) error {
+	headers, err := ParseDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, h := range headers {
+		if h.Name == name {
+			return fmt.Errorf("vignette %s already exists", h.Code())
+		}
+	}
+	vignettes := lessons(headers)
+	num := 1
+	if len(vignettes) > 0 {
+		num = vignettes[len(vignettes)-1].Num + 1
+	}
+	if after >= 0 {
+		num = after + 1
+	}
+	created := Header{Num: num, Name: name}
+	if created.IsGenerator() {
+		return fmt.Errorf("%s would be numbered as a generative art program", created.Code())
+	}
+	var moves []move
+	for _, h := range vignettes {
+		if h.Num < num {
+			continue
+		}
+		to := Header{Num: h.Num + 1, Name: h.Name}
+		if to.IsGenerator() {
+			return fmt.Errorf("shifting %s would number it as a generative art program", h.Code())
+		}
+		moves = append(moves, move{from: h, to: to})
+	}
+	err = moveVignettes(dir, moves, dryRun)
+	if err != nil {
+		return err
+	}
+	fmt.Println("create", created.Code())
+	if dryRun {
+		return nil
+	}
+	subdir := filepath.Join(dir, created.Code())
+	err = os.Mkdir(subdir, 0o755)
+	if err != nil {
+		return err
+	}
+	files := map[string]string{"README.md": "# " + name + "\n"}
+	for _, lang := range Languages {
+		if lang.Template != "" {
+			files[name+"."+lang.Ext] = lang.Template
+		}
+	}
+	for filename, content := range files {
+		err = os.WriteFile(filepath.Join(subdir, filename), []byte(content), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cmdRenumber implements the renumber subcommand, which numbers vignettes
+// consecutively starting at 1, removing gaps and duplicate numbers.
+func cmdRenumber(args []string) error {
+	flags := flag.NewFlagSet("renumber", flag.ExitOnError)
+	dryRun := flags.Bool("n", false, "print changes without making them")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tagalong renumber [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return fmt.Errorf("%w: unexpected arguments %q", errUsage, flags.Args())
+	}
+	return renumberVignettes(".", *dryRun)
+}
+
+// renumberVignettes numbers the vignettes in dir consecutively starting at 1
+// in the order of their numbers. Vignettes sharing a number keep the order
+// of their names.
+func renumberVignettes(dir string, dryRun bool) error {
+	headers, err := ParseDir(dir)
+	if err != nil {
+		return err
+	}
+	var moves []move
+	for i, h := range lessons(headers) {
+		to := Header{Num: i + 1, Name: h.Name}
+		if to != h {
+			moves = append(moves, move{from: h, to: to})
+		}
+	}
+	return moveVignettes(dir, moves, dryRun)
+}
+
+// lessons returns the headers which do not belong to generative art programs.
+func lessons(headers []Header) []Header {
+	var found []Header
+	for _, h := range headers {
+		if !h.IsGenerator() {
+			found = append(found, h)
+		}
+	}
+	return found
+}
+
+// move is the renumbering of a vignette directory.
+type move struct {
+	from, to Header
+}
+
+// moveVignettes renames the vignette directories in dir as planned by moves.
+// Every target is checked before anything is moved, so that a conflict leaves
+// dir untouched. Directories are moved to temporary names first so that moves
+// may target the directory of another move, in any order.
+func moveVignettes(dir string, moves []move, dryRun bool) error {
+	sources := make(map[string]bool, len(moves))
+	for _, m := range moves {
+		sources[m.from.Code()] = true
+	}
+	targets := make(map[string]bool, len(moves))
+	for _, m := range moves {
+		to := m.to.Code()
+		if targets[to] {
+			return fmt.Errorf("moving %s: %s is the target of another move", m.from.Code(), to)
+		}
+		targets[to] = true
+		if _, err := os.Lstat(filepath.Join(dir, to)); err == nil && !sources[to] {
+			return fmt.Errorf("moving %s: %s already exists", m.from.Code(), to)
+		}
+		if _, err := os.Stat(filepath.Join(dir, m.from.Code())); err != nil {
+			return fmt.Errorf("moving %s: %w", m.from.Code(), err)
+		}
+	}
+	for _, m := range moves {
+		fmt.Println("move", m.from.Code(), "->", m.to.Code())
+	}
+	if dryRun {
+		return nil
+	}
+	tmp := func(m move) string { return filepath.Join(dir, ".moving-"+m.from.Code()) }
+	for _, m := range moves {
+		err := os.Rename(filepath.Join(dir, m.from.Code()), tmp(m))
+		if err != nil {
+			return err
+		}
+	}
+	for _, m := range moves {
+		err := os.Rename(tmp(m), filepath.Join(dir, m.to.Code()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// scaffoldDir creates the vignette directories codes, each with a README, in
+// a temporary directory and returns it.
+func scaffoldDir(t *testing.T, codes ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, code := range codes {
+		err := os.Mkdir(filepath.Join(dir, code), 0o755)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, code, "README.md"), []byte("# "+code+"\n"), 0o644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// dirEntries returns the names of the entries of dir.
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestNewVignette(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		codes []string
+		after int
+		want  []string
+	}{
+		{
+			name:  "last",
+			codes: []string{"001-a", "002-b", "901-art"},
+			after: -1,
+			want:  []string{"001-a", "002-b", "003-x", "901-art"},
+		},
+		{
+			name:  "after",
+			codes: []string{"001-a", "002-b", "003-c", "901-art"},
+			after: 1,
+			want:  []string{"001-a", "002-x", "003-b", "004-c", "901-art"},
+		},
+		{
+			name:  "first",
+			codes: []string{"001-a"},
+			after: 0,
+			want:  []string{"001-x", "002-a"},
+		},
+		{
+			name:  "empty",
+			after: -1,
+			want:  []string{"001-x"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := scaffoldDir(t, test.codes...)
+			err := newVignette(dir, "x", test.after, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dirEntries(t, dir); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			// Shifted vignettes keep their content.
+			for _, code := range test.codes {
+				h, _ := ParseHeader(code)
+				if h.Num > test.after && test.after >= 0 && !h.IsGenerator() {
+					h.Num++
+				}
+				b, err := os.ReadFile(filepath.Join(dir, h.Code(), "README.md"))
+				if err != nil || string(b) != "# "+code+"\n" {
+					t.Errorf("%s README: got %q, %v", h.Code(), b, err)
+				}
+			}
+			var created string
+			for _, code := range test.want {
+				if strings.HasSuffix(code, "-x") {
+					created = code
+				}
+			}
+			for _, lang := range Languages {
+				if lang.Template == "" {
+					continue
+				}
+				if _, err := os.Stat(filepath.Join(dir, created, "x."+lang.Ext)); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+}
+
+func TestNewVignetteRejected(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		codes    []string
+		vignette string
+		after    int
+		want     string
+	}{
+		{name: "exists", codes: []string{"001-a", "002-x"}, vignette: "x", after: 0, want: "002-x already exists"},
+		{name: "generator exists", codes: []string{"001-a", "901-x"}, vignette: "x", after: -1, want: "901-x already exists"},
+		{name: "generator number", codes: []string{"899-a"}, vignette: "x", after: -1, want: "generative art"},
+		// A plain file in the way of a shift is found before anything moves.
+		{name: "target taken", codes: []string{"001-a", "002-b", "003-c"}, vignette: "x", after: 1, want: "004-c already exists"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := scaffoldDir(t, test.codes...)
+			if test.name == "target taken" {
+				err := os.WriteFile(filepath.Join(dir, "004-c"), nil, 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := dirEntries(t, dir)
+			err := newVignette(dir, test.vignette, test.after, false)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("got error %v, want one containing %q", err, test.want)
+			}
+			if got := dirEntries(t, dir); !reflect.DeepEqual(got, before) {
+				t.Errorf("directory changed to %q, want %q", got, before)
+			}
+		})
+	}
+}
+
+func TestRenumberVignettes(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		codes []string
+		want  []string
+	}{
+		{
+			name:  "gaps",
+			codes: []string{"002-a", "005-b", "007-c", "901-art"},
+			want:  []string{"001-a", "002-b", "003-c", "901-art"},
+		},
+		{
+			// 002-b moves to a higher number.
+			name:  "duplicates",
+			codes: []string{"001-a", "001-b", "002-c", "003-d"},
+			want:  []string{"001-a", "002-b", "003-c", "004-d"},
+		},
+		{
+			name:  "consecutive",
+			codes: []string{"001-a", "002-b"},
+			want:  []string{"001-a", "002-b"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := scaffoldDir(t, test.codes...)
+			err := renumberVignettes(dir, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dirEntries(t, dir); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScaffoldDryRun(t *testing.T) {
+	codes := []string{"001-a", "003-b", "004-c"}
+	dir := scaffoldDir(t, codes...)
+	err := newVignette(dir, "x", 1, true)
+	if err == nil {
+		err = renumberVignettes(dir, true)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := dirEntries(t, dir); !reflect.DeepEqual(got, codes) {
+		t.Errorf("dry run changed directory to %q", got)
+	}
+}
+
+func TestScaffoldUsage(t *testing.T) {
+	for name, err := range map[string]error{
+		"new without name":    cmdNew(nil),
+		"new extra args":      cmdNew([]string{"x", "y"}),
+		"renumber extra args": cmdRenumber([]string{"x"}),
+	} {
+		if !errors.Is(err, errUsage) {
+			t.Errorf("%s: got error %v, want usage error", name, err)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var servePage = template.Must(template.New("serve").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Tagalong preview</title>
+<link rel="stylesheet" href="tagalong.css">
+</head>
+<body>
+<main>
+{{- with .Err}}
+<section class="output">
+<h3>Error</h3>
+<pre>{{.}}</pre>
+</section>
+{{- end}}
+<article class="readme">
+{{.Document}}
+</article>
+</main>
+<script>new EventSource("events").onmessage = function() { location.reload(); };</script>
+</body>
+</html>
+`))
+
+// cmdServe implements the serve subcommand, which serves a live preview of
+// tagalong.md over HTTP. Vignette directories are polled for changes and only
+// the vignettes whose programs or metadata changed are run again. Open pages
+// reload when the document changes.
+func cmdServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("http", "localhost:8000", "address to serve the preview on")
+	interval := flags.Duration("interval", time.Second, "polling interval of vignette directories")
+	var limits Limits
+	flags.DurationVar(&limits.Timeout, "timeout", time.Minute, "wall time limit of each program run, 0 means no limit")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tagalong serve [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	tmpdir, err := os.MkdirTemp("", "decaf-serve")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+	p := &preview{
+		dir:      ".",
+		executor: NewExecutor(tmpdir, DefaultBuildDir(), limits),
+		clients:  make(map[chan struct{}]bool),
+	}
+	p.update()
+	go func() {
+		for range time.Tick(*interval) {
+			p.update()
+		}
+	}()
+	http.HandleFunc("/", p.serveDocument)
+	http.HandleFunc("/events", p.serveEvents)
+	http.HandleFunc("/tagalong.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		w.Write([]byte(siteCSS))
+	})
+	log.Printf("serving preview at http://%s", *addr)
+	return http.ListenAndServe(*addr, nil)
+}
+
+// preview holds the rendered document served by the serve subcommand.
+type preview struct {
+	dir      string
+	executor *Executor
+
+	// fingerprint identifies the state of the vignette directories when the
+	// document was last rendered. It is only accessed by update.
+	fingerprint string
+	vignettes   []Vignette
+	results     []Result
+	// retry is when to render again although the vignette directories did
+	// not change, so that transient failures are retried. It is zero if
+	// there are none. It is only accessed by update.
+	retry time.Time
+
+	mu       sync.Mutex
+	document template.HTML
+	err      error
+	clients  map[chan struct{}]bool // Event channels of connected pages.
+}
+
+// retryInterval is how long after a transient failure of a program it is run again.
+const retryInterval = 10 * time.Second
+
+// update renders the document again if the vignette directories changed or
+// a program failed transiently and notifies connected pages.
+func (p *preview) update() {
+	fingerprint, err := dirFingerprint(p.dir)
+	if err == nil && fingerprint == p.fingerprint && (p.retry.IsZero() || time.Now().Before(p.retry)) {
+		return
+	}
+	p.fingerprint = fingerprint
+	p.retry = time.Time{}
+	var document bytes.Buffer
+	if err == nil {
+		err = p.render(&document)
+	}
+	if err != nil {
+		log.Println(err)
+	}
+	for _, res := range p.results {
+		if transient(res) {
+			p.retry = time.Now().Add(retryInterval)
+			break
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+	if err == nil {
+		p.document = MarkdownHTML(document.String())
+	}
+	for c := range p.clients {
+		select {
+		case c <- struct{}{}:
+		default: // The page has a reload pending.
+		}
+	}
+}
+
+// render parses the vignettes, runs the programs of those which changed
+// or failed transiently since the last render and writes tagalong.md to document.
+func (p *preview) render(document *bytes.Buffer) error {
+	vignettes, err := ParseDirExercises(p.dir)
+	if err != nil {
+		return err
+	}
+	previous := make(map[string]int, len(p.vignettes))
+	for i, v := range p.vignettes {
+		previous[v.Code()] = i
+	}
+	results := make([]Result, len(vignettes))
+	var changed []int
+	for i, v := range vignettes {
+		j, ok := previous[v.Code()]
+		if ok && sameProgram(v, p.vignettes[j]) && !transient(p.results[j]) {
+			results[i] = p.results[j]
+			continue
+		}
+		if runsGo(v) {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) > 0 {
+		subset := make([]Vignette, len(changed))
+		for k, i := range changed {
+			subset[k] = vignettes[i]
+		}
+		for k, res := range p.executor.ExecuteVignettes(subset, LangGo) {
+			results[changed[k]] = res
+		}
+		log.Printf("ran %d changed vignettes", len(changed))
+	}
+	p.vignettes, p.results = vignettes, results
+	err = ExpandREADMEs(vignettes, results)
+	if err != nil {
+		return err
+	}
+	for _, doc := range Documents {
+		if doc.Doc == DocTagalong {
+			doc.Render(document, vignettes, results)
+		}
+	}
+	return nil
+}
+
+// sameProgram reports whether a and b have the same Go program and metadata,
+// so that running either program gives the same result.
+func sameProgram(a, b Vignette) bool {
+	return a.Programs[LangGo.Ext] == b.Programs[LangGo.Ext] &&
+		reflect.DeepEqual(a.Modules[LangGo.Ext], b.Modules[LangGo.Ext]) &&
+		reflect.DeepEqual(a.Meta, b.Meta)
+}
+
+// transient reports whether res is a failure which may not happen again, i.e:
+// a timeout on a busy machine, rather than a compile error or the program
+// exiting with an error.
+func transient(res Result) bool {
+	return res.Err != nil && res.ExitCode < 0 && !errors.Is(res.Err, ErrBuild)
+}
+
+// dirFingerprint returns a hash of the names, sizes and modification times of
+// the files in the vignette directories of dir, which changes when they do.
+func dirFingerprint(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || name[0] < '0' || name[0] > '9' {
+			continue
+		}
+		err = filepath.WalkDir(filepath.Join(dir, name), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func (p *preview) serveDocument(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	p.mu.Lock()
+	data := struct {
+		Document template.HTML
+		Err      error
+	}{Document: p.document, Err: p.err}
+	p.mu.Unlock()
+	var buf bytes.Buffer
+	err := servePage.Execute(&buf, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// serveEvents streams server-sent events to a page, one per document change.
+func (p *preview) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	c := make(chan struct{}, 1)
+	p.mu.Lock()
+	p.clients[c] = true
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.clients, c)
+		p.mu.Unlock()
+	}()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-c:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	write := func(filename, content string) {
+		t.Helper()
+		filename = filepath.Join(dir, filepath.FromSlash(filename))
+		err := os.MkdirAll(filepath.Dir(filename), 0o755)
+		if err == nil {
+			err = os.WriteFile(filename, []byte(content), 0o644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	fingerprint := func() string {
+		t.Helper()
+		f, err := dirFingerprint(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	write("001-hello/README.md", "# Hello\n")
+	write("001-hello/hello.go", "package main\n")
+	last := fingerprint()
+	if f := fingerprint(); f != last {
+		t.Fatal("fingerprint changed without changes")
+	}
+	for _, test := range []struct {
+		name     string
+		filename string
+		changes  bool
+	}{
+		{name: "edit program", filename: "001-hello/hello.go", changes: true},
+		{name: "add file", filename: "001-hello/expected.txt", changes: true},
+		{name: "add vignette", filename: "002-bye/README.md", changes: true},
+		{name: "add module file", filename: "002-bye/go/main.go", changes: true},
+		{name: "other directory", filename: "docs/notes.md"},
+		{name: "top level file", filename: "tagalong.md"},
+	} {
+		write(test.filename, "changed by "+test.name+"\n")
+		f := fingerprint()
+		if changed := f != last; changed != test.changes {
+			t.Errorf("%s: got fingerprint changed %v, want %v", test.name, changed, test.changes)
+		}
+		last = f
+	}
+}
+
+func TestSameProgram(t *testing.T) {
+	base := func() Vignette {
+		return Vignette{
+			Header:   Header{Num: 1, Name: "hello"},
+			MD:       "# Hello\n",
+			Programs: map[string]string{"go": "package main\n", "py": "print('hi')\n"},
+			Meta:     Meta{Args: []string{"-n", "1"}},
+		}
+	}
+	for _, test := range []struct {
+		name   string
+		modify func(v *Vignette)
+		same   bool
+	}{
+		{name: "unchanged", modify: func(v *Vignette) {}, same: true},
+		{name: "README", modify: func(v *Vignette) { v.MD = "# Hi\n" }, same: true},
+		{name: "Python program", modify: func(v *Vignette) { v.Programs["py"] = "print('bye')\n" }, same: true},
+		{name: "Go program", modify: func(v *Vignette) { v.Programs["go"] = "package main // bye\n" }},
+		{name: "module", modify: func(v *Vignette) {
+			v.Programs["go"] = ""
+			v.Modules = map[string][]File{"go": {{Path: "main.go", Content: "package main\n"}}}
+		}},
+		{name: "args", modify: func(v *Vignette) { v.Meta.Args = []string{"-n", "2"} }},
+		{name: "stdin", modify: func(v *Vignette) { v.Meta.Stdin = "hi\n" }},
+	} {
+		a, b := base(), base()
+		test.modify(&b)
+		if got := sameProgram(a, b); got != test.same {
+			t.Errorf("%s: got sameProgram %v, want %v", test.name, got, test.same)
+		}
+	}
+}
+
+func TestPreviewRetriesTransientFailures(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go")
+	}
+	dir := t.TempDir()
+	err := os.Mkdir(filepath.Join(dir, "001-hello"), 0o755)
+	for filename, content := range map[string]string{
+		"README.md": "# Hello\n",
+		"hello.go":  "package main\n\nfunc main() { println(\"hi\") }\n",
+		"hello.py":  "print('hi')\n",
+	} {
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, "001-hello", filename), []byte(content), 0o644)
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpdir := t.TempDir()
+	p := &preview{dir: dir, executor: NewExecutor(tmpdir, filepath.Join(tmpdir, "build"), Limits{Timeout: time.Minute})}
+	p.update()
+	if p.err != nil || len(p.results) != 1 || p.results[0].Err != nil || !p.retry.IsZero() {
+		t.Fatalf("first update: got results %+v, error %v, retry at %v", p.results, p.err, p.retry)
+	}
+
+	// A program failing to run is run again after retryInterval without changes.
+	p.results[0] = Result{ExitCode: -1, Err: ErrTimeout}
+	p.retry = time.Now().Add(-time.Second)
+	p.update()
+	if p.results[0].Err != nil || p.results[0].Stderr != "hi\n" {
+		t.Errorf("retry: got result %+v, want the program run again", p.results[0])
+	}
+
+	// Programs exiting with an error are only run again when they change,
+	// even if the document is rendered again.
+	failed := Result{ExitCode: 1, Err: errors.New("exit status 1")}
+	p.results[0] = failed
+	p.retry = time.Now().Add(-time.Second)
+	p.update()
+	if p.results[0].ExitCode != failed.ExitCode || !p.retry.IsZero() {
+		t.Errorf("program failure: got result %+v, retry at %v, want it kept", p.results[0], p.retry)
+	}
+}
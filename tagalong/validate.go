@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// auxiliaryFiles are files vignette directories may hold besides READMEs and programs.
+var auxiliaryFiles = []string{MetaFilename, ExpectedFilename, ExerciseFilename, ExerciseTestFilename}
+
+// DirProblems lists the problems found in a directory of vignettes.
+type DirProblems []error
+
+func (p DirProblems) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d problems found:", len(p))
+	for _, err := range p {
+		b.WriteString("\n\t" + err.Error())
+	}
+	return b.String()
+}
+
+// ValidateDir strictly checks the vignette directories in dir, which are the
+// directories whose name starts with a digit. It reports malformed names,
+// duplicate numbers, gaps in numbering, vignettes missing a README, Go programs
+// without a Python twin and vice versa and files which match no language.
+// The returned error is of type DirProblems if problems are found.
+func ValidateDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var problems DirProblems
+	var found []Header
+	codeByNum := make(map[int]string)
+	for _, entry := range entries {
+		filename := entry.Name()
+		if !entry.IsDir() || filename[0] < '0' || filename[0] > '9' {
+			continue
+		}
+		h, err := ParseHeader(filename)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: malformed vignette name: %w", filename, err))
+			continue
+		}
+		if other, ok := codeByNum[h.Num]; ok {
+			problems = append(problems, fmt.Errorf("%s: number %03d already used by %s", filename, h.Num, other))
+			continue
+		}
+		codeByNum[h.Num] = filename
+		found = append(found, h)
+		if !h.IsGenerator() {
+			problems = append(problems, validateVignetteDir(filepath.Join(dir, filename), h)...)
+		}
+	}
+	sort.Sort(ByHeaderNumber(found))
+	for i := 1; i < len(found); i++ {
+		prev, h := found[i-1], found[i]
+		if !h.IsGenerator() && h.Num != prev.Num+1 {
+			problems = append(problems, fmt.Errorf("%s: numbering gap after %s", h.Code(), prev.Code()))
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+func validateVignetteDir(subdir string, h Header) (problems []error) {
+	entries, err := os.ReadDir(subdir)
+	if err != nil {
+		return []error{err}
+	}
+	hasREADME := false
+	programs := make(map[string]bool)
+	for _, entry := range entries {
+		filename := entry.Name()
+		ext := strings.TrimPrefix(filename, h.Name+".")
+		if entry.IsDir() {
+			ext = filename
+		}
+		_, isLang := LookupLanguage(ext)
+		switch {
+		case filename == "README.md" && !entry.IsDir():
+			hasREADME = true
+		case isLang && (entry.IsDir() || ext != filename):
+			if programs[ext] {
+				problems = append(problems, fmt.Errorf("%s: both %s.%s and %s/ hold a program", h.Code(), h.Name, ext, ext))
+			}
+			programs[ext] = true
+		case !entry.IsDir() && contains(auxiliaryFiles, filename):
+		default:
+			problems = append(problems, fmt.Errorf("%s: stray file %s matches no language, programs must be named %s.<ext>", h.Code(), filename, h.Name))
+		}
+	}
+	if !hasREADME {
+		problems = append(problems, fmt.Errorf("%s: missing README.md", h.Code()))
+	}
+	if programs[LangGo.Ext] && !programs[LangPython.Ext] {
+		problems = append(problems, fmt.Errorf("%s: Go program has no Python twin", h.Code()))
+	}
+	if programs[LangPython.Ext] && !programs[LangGo.Ext] {
+		problems = append(problems, fmt.Errorf("%s: Python program has no Go twin", h.Code()))
+	}
+	return problems
+}
+
+func contains(list []string, s string) bool {
+	for _, elem := range list {
+		if elem == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateDir(t *testing.T) {
+	dir := t.TempDir()
+	for filename, content := range map[string]string{
+		"001-intro/README.md":    "# Intro\n",
+		"02-short/README.md":     "# Short\n",
+		"002-hello/README.md":    "# Hello\n",
+		"002-hello/hello.go":     "package main\n",
+		"001-dup/README.md":      "# Dup\n",
+		"003-bye/README.md":      "# Bye\n",
+		"003-bye/bye.py":         "print('bye')\n",
+		"003-bye/notes.txt":      "stray\n",
+		"005-gap/README.md":      "# Gap\n",
+		"901-art/art.go":         "package main\n",
+		"006-missing/missing.go": "package main\n",
+		"006-missing/missing.py": "print()\n",
+	} {
+		filename = filepath.Join(dir, filepath.FromSlash(filename))
+		err := os.MkdirAll(filepath.Dir(filename), 0o755)
+		if err == nil {
+			err = os.WriteFile(filename, []byte(content), 0o644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := ValidateDir(dir)
+	var problems DirProblems
+	if !errors.As(err, &problems) {
+		t.Fatalf("got error %v, want DirProblems", err)
+	}
+	got := make([]string, len(problems))
+	for i, p := range problems {
+		got[i] = p.Error()
+	}
+	for _, want := range []string{
+		"02-short: malformed vignette name",
+		"002-hello: Go program has no Python twin",
+		"number 001 already used by",
+		"003-bye: Python program has no Go twin",
+		"003-bye: stray file notes.txt",
+		"005-gap: numbering gap after 003-bye",
+		"006-missing: missing README.md",
+	} {
+		found := false
+		for _, p := range got {
+			found = found || strings.Contains(p, want)
+		}
+		if !found {
+			t.Errorf("missing problem %q in:\n%s", want, strings.Join(got, "\n"))
+		}
+	}
+	if len(got) != 7 {
+		t.Errorf("got %d problems, want 7:\n%s", len(got), strings.Join(got, "\n"))
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	for _, test := range []struct {
+		code string
+		want Header
+		ok   bool
+	}{
+		{code: "009-if", want: Header{Num: 9, Name: "if"}, ok: true},
+		{code: "013-slices", want: Header{Num: 13, Name: "slices"}, ok: true},
+		{code: "001-a", want: Header{Num: 1, Name: "a"}, ok: true},
+		{code: "001-"},
+		{code: "01-ab"},
+		{code: "001_ab"},
+		{code: "abc-def"},
+	} {
+		got, err := ParseHeader(test.code)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("ParseHeader(%q) = %+v, %v, want %+v, ok %v", test.code, got, err, test.want, test.ok)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Divergence is a vignette whose Python program output does not match the output
+// of its Go twin.
+type Divergence struct {
+	Header
+	// Diff is a unified diff between the Go and Python outputs.
+	Diff string
+	// Err is non-nil if the Python program failed to run.
+	Err error
+}
+
+// VerifyPython runs the Python program of every vignette that has both a Python
+// and a Go program and compares its output with the vignette's Go program result
+// found in goResults. If normalize is true known formatting differences between
+// the languages are ignored, see [NormalizeOutput].
+func VerifyPython(e *Executor, vignettes []Vignette, goResults []Result, normalize bool) []Divergence {
+	found := make([]*Divergence, len(vignettes))
+	parallel(len(vignettes), func(i int) {
+		vignette := vignettes[i]
+		if !vignette.HasProgram(LangGo.Ext) || !vignette.HasProgram(LangPython.Ext) {
+			return
+		}
+		py := e.Execute(&vignette, LangPython)
+		if py.Err != nil {
+			found[i] = &Divergence{Header: vignette.Header, Err: py.Err}
+			return
+		}
+		goOutput, pyOutput := goResults[i].Output, py.Output
+		if normalize {
+			goOutput = NormalizeOutput(goOutput)
+			pyOutput = NormalizeOutput(pyOutput)
+		}
+		diff := UnifiedDiff(vignette.Name+"."+LangGo.Ext, vignette.Name+"."+LangPython.Ext, goOutput, pyOutput)
+		if diff != "" {
+			found[i] = &Divergence{Header: vignette.Header, Diff: diff}
+		}
+	})
+	var divergences []Divergence
+	for _, d := range found {
+		if d != nil {
+			divergences = append(divergences, *d)
+		}
+	}
+	return divergences
+}
+
+var (
+	pyBool  = regexp.MustCompile(`\b(True|False)\b`)
+	pyFloat = regexp.MustCompile(`\b(\d+)\.0(\D|$)`)
+	pyList  = regexp.MustCompile(`\[[^\[\]]*\]`)
+)
+
+// NormalizeOutput rewrites Python's printing conventions to Go's so that outputs
+// which differ only in formatting compare equal. It handles booleans (True->true),
+// integral floats (2.0->2) and lists (['a', 'b']->[a b]).
+func NormalizeOutput(s string) string {
+	s = pyBool.ReplaceAllStringFunc(s, strings.ToLower)
+	s = pyFloat.ReplaceAllString(s, "$1$2")
+	return pyList.ReplaceAllStringFunc(s, func(list string) string {
+		elems := strings.Split(list[1:len(list)-1], ", ")
+		for i, elem := range elems {
+			if len(elem) >= 2 && (elem[0] == '\'' || elem[0] == '"') && elem[len(elem)-1] == elem[0] {
+				elems[i] = elem[1 : len(elem)-1]
+			}
+		}
+		return "[" + strings.Join(elems, " ") + "]"
+	})
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestNormalizeOutput(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{in: "True False\n", want: "true false\n"},
+		{in: "Truest is not True\n", want: "Truest is not true\n"},
+		{in: "2.0 and 10.0\n", want: "2 and 10\n"},
+		{in: "2.05 and 0.5\n", want: "2.05 and 0.5\n"},
+		{in: "total: 3.0", want: "total: 3"},
+		{in: "['a', 'b']\n", want: "[a b]\n"},
+		{in: `["a", 'b', 3]`, want: "[a b 3]"},
+		{in: "[1, 2.0, True]\n", want: "[1 2 true]\n"},
+		{in: "[]\n", want: "[]\n"},
+		{in: "Go output [1 2] true 2\n", want: "Go output [1 2] true 2\n"},
+	} {
+		if got := NormalizeOutput(test.in); got != test.want {
+			t.Errorf("NormalizeOutput(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}